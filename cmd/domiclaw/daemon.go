@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/agent"
+	"github.com/DomiYoung/domiclaw/pkg/config"
+	"github.com/DomiYoung/domiclaw/pkg/heartbeat"
+	"github.com/DomiYoung/domiclaw/pkg/logger"
+	"github.com/DomiYoung/domiclaw/pkg/memory"
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// defaultHeartbeatTools are offered to heartbeat turns unless
+// heartbeat.tools is set: the read-only tools plus notes and memory.
+var defaultHeartbeatTools = append(append([]string{}, agent.ReadOnlyTools...), "note", "remember")
+
+func runDaemon(args []string) {
+	var workspace string
+	var runJob string
+	once := false
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-w", "--workspace":
+			if i+1 < len(args) {
+				workspace = args[i+1]
+				i++
+			}
+		case "--once":
+			once = true
+		case "--run":
+			if i+1 < len(args) {
+				runJob = args[i+1]
+				i++
+			}
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.ErrorF("Failed to load config", map[string]interface{}{
+			"error": err.Error(),
+		})
+		os.Exit(1)
+	}
+
+	if workspace != "" {
+		cfg.Workspace = workspace
+	}
+
+	var quiet *heartbeat.QuietHours
+	if cfg.Heartbeat.QuietHours != "" {
+		if quiet, err = heartbeat.ParseQuietHours(cfg.Heartbeat.QuietHours); err != nil {
+			fmt.Printf("Error: heartbeat.quiet_hours: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	jobs, err := loadJobs(cfg)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	workingDir, err := os.Getwd()
+	if err != nil {
+		workingDir = cfg.WorkspacePath()
+	}
+	// The heartbeat's loop; each job run gets its own
+	loop, err := newDaemonLoop(cfg, workingDir)
+	if err != nil {
+		logger.ErrorF("Failed to create agent", map[string]interface{}{
+			"error": err.Error(),
+		})
+		os.Exit(1)
+	}
+	defer loop.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	mem := memory.NewStore(cfg.WorkspacePath())
+	heartbeatTools := cfg.Heartbeat.Tools
+	if len(heartbeatTools) == 0 {
+		heartbeatTools = defaultHeartbeatTools
+	}
+
+	hb := heartbeat.NewService(
+		cfg.WorkspacePath(),
+		func(prompt string) (string, error) {
+			reply, err := loop.RunTurn(ctx, prompt, agent.TurnOptions{Tools: heartbeatTools})
+			if err != nil {
+				return "", err
+			}
+			if !heartbeat.IsNothingToDo(reply) {
+				if err := mem.AppendToday(formatDaemonNote("Heartbeat", reply)); err != nil {
+					logger.WarnCF("heartbeat", "Failed to write daily note", map[string]interface{}{
+						"error": err.Error(),
+					})
+				}
+			}
+			return reply, nil
+		},
+		cfg.Heartbeat.IntervalSeconds,
+		cfg.Heartbeat.Enabled,
+	)
+	hb.SetQuietHours(quiet)
+	if cfg.Memory.ConsolidateOnHeartbeat {
+		hb.SetHousekeeping(func() {
+			result, err := consolidateMemory(ctx, cfg, loop, false)
+			if err != nil {
+				logger.WarnCF("heartbeat", "Memory consolidation failed", map[string]interface{}{
+					"error": err.Error(),
+				})
+				return
+			}
+			if len(result.Notes) > 0 {
+				logger.InfoCF("heartbeat", "Consolidated daily notes", map[string]interface{}{
+					"notes": len(result.Notes),
+					"facts": result.Facts,
+				})
+			}
+		})
+	}
+
+	hb.SetJobs(jobs, func(ctx context.Context, job *heartbeat.Job) (string, error) {
+		return runScheduledJob(ctx, cfg, workingDir, mem, job, heartbeatTools)
+	})
+
+	if runJob != "" {
+		for _, job := range jobs {
+			if job.Name == runJob {
+				run := hb.RunJob(ctx, job)
+				if run.Status != "ok" {
+					fmt.Printf("Error: %s\n", run.Error)
+					os.Exit(1)
+				}
+				fmt.Println(run.Output)
+				return
+			}
+		}
+		fmt.Printf("Error: no job named %q\n", runJob)
+		os.Exit(1)
+	}
+
+	if once {
+		reply, err := hb.RunOnce()
+		if err != nil {
+			os.Exit(1)
+		}
+		if reply == "" {
+			fmt.Printf("Nothing to do (no open tasks in %s, or quiet hours).\n", hb.NotesPath())
+		} else {
+			fmt.Println(reply)
+		}
+		return
+	}
+
+	if err := hb.Start(ctx); err != nil {
+		fmt.Printf("Error: %v (set heartbeat.enabled in %s)\n", err, config.ConfigPath())
+		os.Exit(1)
+	}
+	defer hb.Stop()
+
+	quietStatus := "none"
+	if quiet != nil {
+		quietStatus = quiet.String()
+	}
+	if cfg.Heartbeat.Enabled {
+		fmt.Printf("DomiClaw daemon running: heartbeat every %ds, quiet hours %s\n", cfg.Heartbeat.IntervalSeconds, quietStatus)
+		fmt.Printf("Tasks: %s\n", hb.NotesPath())
+	} else {
+		fmt.Println("DomiClaw daemon running: heartbeat disabled")
+	}
+	now := time.Now()
+	for _, job := range jobs {
+		fmt.Printf("Job %s: %s (next %s)\n", job.Name, job.Schedule, job.Next(now).Format("2006-01-02 15:04"))
+	}
+	fmt.Println("Press Ctrl+C to stop.")
+
+	<-ctx.Done()
+	logger.Info("DomiClaw daemon stopped")
+}
+
+// loadJobs returns the scheduled jobs from config and the workspace's
+// SCHEDULE.md.
+func loadJobs(cfg *config.Config) ([]*heartbeat.Job, error) {
+	var jobs []*heartbeat.Job
+	for _, jc := range cfg.Heartbeat.Jobs {
+		job := &heartbeat.Job{
+			Name:      jc.Name,
+			Schedule:  jc.Schedule,
+			Prompt:    jc.Prompt,
+			Tools:     jc.Tools,
+			Workspace: jc.Workspace,
+		}
+		if err := job.Parse(); err != nil {
+			return nil, fmt.Errorf("heartbeat.jobs: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	fileJobs, err := heartbeat.LoadScheduleFile(cfg.WorkspacePath())
+	if err != nil {
+		return nil, err
+	}
+	jobs = append(jobs, fileJobs...)
+
+	seen := make(map[string]bool)
+	for _, job := range jobs {
+		if seen[job.Name] {
+			return nil, fmt.Errorf("duplicate job name %q", job.Name)
+		}
+		seen[job.Name] = true
+	}
+	return jobs, nil
+}
+
+// newDaemonLoop creates an agent loop working in dir for unattended turns,
+// sandboxed with the autonomous profile.
+func newDaemonLoop(cfg *config.Config, dir string) (*agent.Loop, error) {
+	loop, err := agent.NewLoopAt(cfg, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := loop.SetSandboxProfile("autonomous"); err != nil {
+		loop.Close()
+		return nil, err
+	}
+	return loop, nil
+}
+
+// runScheduledJob runs a job as a restricted agent turn, in the job's own
+// working directory if it has one, and logs the outcome to the daily note.
+func runScheduledJob(ctx context.Context, cfg *config.Config, workingDir string, mem *memory.Store, job *heartbeat.Job, defaultTools []string) (string, error) {
+	reply, err := runJobTurn(ctx, cfg, workingDir, job, defaultTools)
+
+	note := formatDaemonNote("Job "+job.Name, reply)
+	if err != nil {
+		note = formatDaemonNote("Job "+job.Name+" failed", err.Error())
+	}
+	if werr := mem.AppendToday(note); werr != nil {
+		logger.WarnCF("heartbeat", "Failed to write daily note", map[string]interface{}{
+			"error": werr.Error(),
+		})
+	}
+	return reply, err
+}
+
+// runJobTurn runs the agent turn for a job. Jobs can run at the same time as
+// each other and the heartbeat, so each run gets its own loop: a cd or
+// export in one job's shell doesn't leak into another.
+func runJobTurn(ctx context.Context, cfg *config.Config, workingDir string, job *heartbeat.Job, defaultTools []string) (string, error) {
+	dir := workingDir
+	if job.Workspace != "" {
+		dir = utils.ExpandPath(job.Workspace)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("workspace %s is not a directory", dir)
+		}
+	}
+	loop, err := newDaemonLoop(cfg, dir)
+	if err != nil {
+		return "", err
+	}
+	defer loop.Close()
+
+	jobTools := job.Tools
+	switch {
+	case len(jobTools) == 0:
+		jobTools = defaultTools
+	case len(jobTools) == 1 && jobTools[0] == "*":
+		jobTools = loop.GetTools().List()
+	}
+
+	prompt := fmt.Sprintf("# Scheduled Job: %s\n\nCurrent time: %s\n\n%s",
+		job.Name, time.Now().Format("2006-01-02 15:04 Monday"), job.Prompt)
+	return loop.RunTurn(ctx, prompt, agent.TurnOptions{Tools: jobTools})
+}
+
+// formatDaemonNote formats a heartbeat or job reply as a daily note entry.
+func formatDaemonNote(label, reply string) string {
+	lines := strings.Split(strings.TrimSpace(reply), "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = "  " + lines[i]
+		}
+	}
+	return fmt.Sprintf("- [%s] %s: %s\n", time.Now().Format("15:04"), label, strings.Join(lines, "\n"))
+}
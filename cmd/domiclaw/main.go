@@ -10,9 +10,11 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/DomiYoung/domiclaw/pkg/agent"
 	"github.com/DomiYoung/domiclaw/pkg/config"
+	"github.com/DomiYoung/domiclaw/pkg/git"
 	"github.com/DomiYoung/domiclaw/pkg/heartbeat"
 	"github.com/DomiYoung/domiclaw/pkg/logger"
 	"github.com/DomiYoung/domiclaw/pkg/memory"
@@ -48,6 +50,12 @@ func main() {
 		runResume()
 	case "status":
 		runStatus()
+	case "memory":
+		runMemory(os.Args[2:])
+	case "daemon":
+		runDaemon(os.Args[2:])
+	case "tasks":
+		runTasks(os.Args[2:])
 	case "help", "-h", "--help":
 		printUsage()
 	default:
@@ -64,21 +72,31 @@ Usage: domiclaw <command> [options]
 
 Commands:
   init      Initialize workspace and config
-  run       Run agent with a single prompt
-  chat      Interactive chat mode (REPL)
-  auto      Autonomous mode - self-directed task execution
+  run       Run agent with a single prompt (--plan: plan first, then approve)
+  chat      Interactive chat mode (REPL, --plan to start in plan mode)
+  auto      Autonomous mode - self-directed task execution (--worktree, --resume)
   resume    Resume from last session (after context overflow)
   status    Show current status
+  memory    Search or consolidate memory (memory search|consolidate)
+  daemon    Run the heartbeat and scheduled jobs (--once, --run <job>)
+  tasks     Queue autonomous tasks and run them (tasks add|list|work|...)
   version   Show version information
   help      Show this help message
 
 Examples:
   domiclaw init
   domiclaw run -m "Help me refactor this code"
+  domiclaw run --plan -m "Add caching to the API client"  # Approve before any change
   domiclaw chat                    # Enter interactive mode
   domiclaw chat -w /path/to/proj   # Chat in specific directory
   domiclaw auto "逆向 Claude Code 插件，开发完整版桌面应用"
+  domiclaw auto --worktree "Add rate limiting"  # Work on a new branch
+  domiclaw auto --resume           # Continue the last autonomous run
   domiclaw resume
+  domiclaw memory search "deploy script"
+  domiclaw daemon                  # Act on memory/HEARTBEAT.md periodically
+  domiclaw tasks add "Fix the flaky login test"
+  domiclaw tasks work              # Run queued tasks one after another
 
 Environment Variables:
   ANTHROPIC_API_KEY    Anthropic API key
@@ -89,6 +107,7 @@ Environment Variables:
   TAVILY_API_KEY       Tavily search API key
   TAVILY_API_KEY_1~5   Tavily keys for rotation (auto-random)
   BRAVE_API_KEY        Brave Search API key
+  SEARXNG_URL          SearXNG instance URL (e.g. http://searx.lan:8080)
 
 Configuration: ~/.domiclaw/config.json
 `)
@@ -148,6 +167,7 @@ func runAgent(args []string) {
 	// Parse arguments
 	var prompt string
 	var workspace string
+	plan := false
 
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
@@ -161,6 +181,8 @@ func runAgent(args []string) {
 				workspace = args[i+1]
 				i++
 			}
+		case "--plan":
+			plan = true
 		}
 	}
 
@@ -194,6 +216,19 @@ func runAgent(args []string) {
 		fmt.Println("  export ANTHROPIC_API_KEY=\"your-api-key\"")
 		os.Exit(1)
 	}
+	defer loop.Close()
+
+	// A prompt like "/review main.go" runs a custom command
+	cwd, _ := os.Getwd()
+	expanded, allowed, ok, err := expandSlash(cwd, prompt)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if ok {
+		prompt = expanded
+		loop.SetAllowedTools(allowed)
+	}
 
 	// Setup context with signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -202,25 +237,13 @@ func runAgent(args []string) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start heartbeat if enabled
-	var hb *heartbeat.Service
-	if cfg.Heartbeat.Enabled {
-		hb = heartbeat.NewService(
-			cfg.WorkspacePath(),
-			func(p string) (string, error) {
-				logger.DebugCF("heartbeat", "Heartbeat triggered", nil)
-				return "", nil
-			},
-			cfg.Heartbeat.IntervalSeconds,
-			true,
-		)
-		hb.Start(ctx)
-		defer hb.Stop()
-	}
-
 	// Run in goroutine to handle signals
 	errChan := make(chan error, 1)
 	go func() {
+		if plan {
+			errChan <- runPlanned(ctx, loop, prompt)
+			return
+		}
 		errChan <- loop.Run(ctx, prompt)
 	}()
 
@@ -244,6 +267,70 @@ func runAgent(args []string) {
 	logger.Info("DomiClaw finished")
 }
 
+// runPlanned runs prompt in plan mode, with read-only tools, and executes
+// the plan in the same session with all tools once the user approves it.
+// Any other answer is sent back to refine the plan.
+func runPlanned(ctx context.Context, loop *agent.Loop, prompt string) (err error) {
+	status := "rejected"
+	defer func() {
+		if err != nil {
+			status = "error"
+		}
+		loop.EndSession(status, prompt)
+	}()
+	loop.SetPlanMode(true)
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Println("[Plan mode: read-only tools until you approve the plan]")
+
+	request := prompt
+	for {
+		if err := loop.RunContinue(ctx, request); err != nil {
+			return err
+		}
+
+		answer, ok := askPlanApproval(reader)
+		switch {
+		case !ok:
+			fmt.Println("Plan not executed.")
+			return nil
+		case answer == "":
+			// The approved plan runs with every tool, even if a custom
+			// command restricted the planning turns
+			loop.SetPlanMode(false)
+			loop.SetAllowedTools(nil)
+			fmt.Println("\n[Plan approved: executing with all tools]")
+			status = "done"
+			err := loop.RunContinue(ctx, agent.PlanApprovedPrompt)
+			fmt.Println()
+			return err
+		}
+		request = answer
+	}
+}
+
+// askPlanApproval asks whether to execute the plan. It returns "" and true
+// on approval, feedback and true to revise the plan, and false to reject
+// it or when stdin is closed.
+func askPlanApproval(reader *bufio.Reader) (string, bool) {
+	for {
+		fmt.Print("\n\nExecute this plan? [y]es, [n]o, or type feedback to revise it: ")
+		line, err := reader.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return "", true
+		case "n", "no":
+			return "", false
+		case "":
+			if err != nil {
+				return "", false
+			}
+			continue
+		}
+		return answer, true
+	}
+}
+
 func runResume() {
 	cfg, err := config.Load()
 	if err != nil {
@@ -260,6 +347,14 @@ func runResume() {
 		os.Exit(0)
 	}
 
+	// An autonomous run that overflowed resumes in autonomous mode
+	if cp, err := agent.LoadCheckpoint(cfg.CheckpointPath()); err == nil && cp != nil && cp.Status == "context_overflow" {
+		fmt.Println("Resuming autonomous run...")
+		mem.ClearResumeTrigger()
+		runAutonomousMode(cfg, cp.WorkingDir, cp.Task, cp)
+		return
+	}
+
 	resumePrompt := mem.ReadResumePrompt()
 	if resumePrompt == "" {
 		fmt.Println("Resume trigger found but no resume prompt. Creating default...")
@@ -286,6 +381,13 @@ func runStatus() {
 
 	mem := memory.NewStore(cfg.WorkspacePath())
 
+	projectStatus := "none (not in a git repository)"
+	if wd, err := os.Getwd(); err == nil {
+		if root := memory.FindProjectRoot(wd); root != "" {
+			projectStatus = memory.NewProjectStore(root).LongTermPath()
+		}
+	}
+
 	// Check API key and provider
 	apiKeyStatus := "not set"
 	providerName := "none"
@@ -304,10 +406,10 @@ func runStatus() {
 		providerName = "openrouter"
 	}
 
-	// Check search key
-	searchKeyStatus := "not set"
-	if cfg.GetSearchAPIKey() != "" {
-		searchKeyStatus = "configured"
+	// Check search backends
+	searchStatus := "not configured"
+	if backends := cfg.SearchBackends(); len(backends) > 0 {
+		searchStatus = strings.Join(backends, " -> ")
 	}
 
 	fmt.Printf(`DomiClaw Status
@@ -319,11 +421,12 @@ Model:          %s
 
 Provider:       %s
 API Key:        %s
-Search Key:     %s
+Search:         %s
 
 Memory:
   Long-term:    %v
   Daily dir:    %s
+  Project:      %s
 
 Heartbeat:      %s (every %ds)
 Strategic:      %s
@@ -335,19 +438,71 @@ Pending Resume: %v
 		cfg.Agents.Model,
 		providerName,
 		apiKeyStatus,
-		searchKeyStatus,
+		searchStatus,
 		mem.ReadLongTerm() != "",
 		cfg.MemoryDir(),
+		projectStatus,
 		boolToStatus(cfg.Heartbeat.Enabled),
 		cfg.Heartbeat.IntervalSeconds,
 		boolToStatus(cfg.StrategicCompact.Enabled),
 		mem.HasPendingResume(),
 	)
+
+	if cp, err := agent.LoadCheckpoint(cfg.CheckpointPath()); err == nil && cp != nil {
+		status := cp.Status
+		if cp.Reason != "" {
+			status += ": " + cp.Reason
+		}
+		fmt.Printf("Auto Run:       %s, %d cycles, %d tokens (%s)\n",
+			utils.Truncate(cp.Task, 50), cp.Cycles, cp.Usage.Total(), status)
+		fmt.Println("                Run 'domiclaw auto --resume' to continue.")
+	}
+
+	printJobStatus(cfg)
+}
+
+// printJobStatus lists the scheduled jobs with their last and next runs.
+func printJobStatus(cfg *config.Config) {
+	jobs, err := loadJobs(cfg)
+	if err != nil {
+		fmt.Printf("\nScheduled Jobs: error: %v\n", err)
+		return
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	history := heartbeat.LoadHistory(cfg.WorkspacePath())
+	last := heartbeat.LastRuns(history)
+	runs := make(map[string]int)
+	for _, run := range history {
+		runs[run.Job]++
+	}
+
+	now := time.Now()
+	fmt.Println("\nScheduled Jobs:")
+	for _, job := range jobs {
+		fmt.Printf("  %s (%s)\n", job.Name, job.Schedule)
+		if run, ok := last[job.Name]; ok {
+			status := run.Status
+			if run.Error != "" {
+				status += ": " + utils.Truncate(run.Error, 80)
+			}
+			fmt.Printf("    Last run:   %s, %s, %s (%d recorded)\n",
+				run.Started.Format("2006-01-02 15:04"), run.Duration, status, runs[job.Name])
+		} else {
+			fmt.Println("    Last run:   never")
+		}
+		if next := job.Next(now); !next.IsZero() {
+			fmt.Printf("    Next run:   %s\n", next.Format("2006-01-02 15:04"))
+		}
+	}
 }
 
 func runChat(args []string) {
 	// Parse arguments
 	var workspace string
+	plan := false
 
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
@@ -356,6 +511,8 @@ func runChat(args []string) {
 				workspace = args[i+1]
 				i++
 			}
+		case "--plan":
+			plan = true
 		}
 	}
 
@@ -383,6 +540,8 @@ func runChat(args []string) {
 		fmt.Println("\nMake sure ANTHROPIC_API_KEY is set.")
 		os.Exit(1)
 	}
+	defer loop.Close()
+	loop.SetPlanMode(plan)
 
 	// Setup context with signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -396,6 +555,8 @@ func runChat(args []string) {
 		<-sigChan
 		fmt.Println("\n\nGoodbye!")
 		loop.Stop()
+		loop.EndSession("interrupted", "")
+		loop.Close()
 		cancel()
 		os.Exit(0)
 	}()
@@ -410,15 +571,27 @@ Type your message and press Enter. Commands:
   /quit, /exit  - Exit chat
   /clear        - Clear conversation history
   /status       - Show status
+  /todo         - Show the agent's todo list
+  /plan         - Plan mode: read-only tools, the agent proposes a plan
+  /approve      - Approve the plan and execute it with all tools
+  /help         - List all commands, including your custom ones
 
 `, cwd)
+	if plan {
+		fmt.Print("[Plan mode: read-only tools until you /approve a plan]\n\n")
+	}
 
 	// Interactive loop
 	reader := bufio.NewReader(os.Stdin)
 	for {
-		fmt.Print("You: ")
+		if loop.PlanMode() {
+			fmt.Print("You (plan): ")
+		} else {
+			fmt.Print("You: ")
+		}
 		input, err := reader.ReadString('\n')
 		if err != nil {
+			loop.EndSession("exit", "")
 			break
 		}
 
@@ -431,6 +604,7 @@ Type your message and press Enter. Commands:
 		switch strings.ToLower(input) {
 		case "/quit", "/exit", "/q":
 			fmt.Println("Goodbye!")
+			loop.EndSession("exit", "")
 			return
 		case "/clear":
 			loop.ClearHistory()
@@ -439,7 +613,45 @@ Type your message and press Enter. Commands:
 		case "/status":
 			runStatus()
 			continue
+		case "/todo", "/todos":
+			if list := loop.Todos(); list.Render() == "" {
+				fmt.Println("[No todo list yet]")
+			} else {
+				fmt.Printf("Todo (%s):\n%s", list.Progress(), list.Render())
+			}
+			continue
+		case "/help":
+			printChatHelp(cwd)
+			continue
+		case "/plan":
+			loop.SetPlanMode(true)
+			fmt.Println("[Plan mode: read-only tools until you /approve a plan]")
+			continue
+		case "/approve":
+			if !loop.PlanMode() {
+				fmt.Println("[Not in plan mode]")
+				continue
+			}
+			loop.SetPlanMode(false)
+			fmt.Println("[Plan approved: executing with all tools]")
+			input = agent.PlanApprovedPrompt
+		}
+
+		// Custom commands from the user's and the project's commands dirs
+		var allowed []string
+		if name, _ := parseSlash(input); strings.HasPrefix(input, "/") && !strings.Contains(name, "/") {
+			prompt, cmdTools, ok, err := expandSlash(cwd, input)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				continue
+			}
+			if !ok {
+				fmt.Printf("[Unknown command /%s - type /help for a list]\n", name)
+				continue
+			}
+			input, allowed = prompt, cmdTools
 		}
+		loop.SetAllowedTools(allowed)
 
 		// Run agent with input (continues conversation)
 		fmt.Print("\nDomiClaw: ")
@@ -452,18 +664,34 @@ Type your message and press Enter. Commands:
 			})
 		}
 		fmt.Println()
+		if loop.PlanMode() {
+			fmt.Println("\n[Plan mode: /approve to execute the plan, or reply to revise it]")
+		}
 	}
 }
 
 func runAuto(args []string) {
-	if len(args) == 0 {
+	resume, worktree := false, false
+	var words []string
+	for _, arg := range args {
+		switch arg {
+		case "--resume":
+			resume = true
+		case "--worktree":
+			worktree = true
+		default:
+			words = append(words, arg)
+		}
+	}
+	if len(words) == 0 && !resume {
 		fmt.Println("Error: Please provide a task description.")
-		fmt.Println("Usage: domiclaw auto \"your task description\"")
+		fmt.Println("Usage: domiclaw auto [--worktree] \"your task description\"")
+		fmt.Println("       domiclaw auto --resume")
 		os.Exit(1)
 	}
 
 	// Join all args as the task description
-	task := strings.Join(args, " ")
+	task := strings.Join(words, " ")
 
 	// Load config
 	cfg, err := config.Load()
@@ -474,8 +702,39 @@ func runAuto(args []string) {
 		os.Exit(1)
 	}
 
+	if resume {
+		cp, err := agent.LoadCheckpoint(cfg.CheckpointPath())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if cp == nil {
+			fmt.Println("No autonomous run to resume.")
+			os.Exit(0)
+		}
+		memory.NewStore(cfg.WorkspacePath()).ClearResumeTrigger()
+		runAutonomousMode(cfg, cp.WorkingDir, cp.Task, cp)
+		return
+	}
+
+	cwd, _ := os.Getwd()
+	if worktree {
+		wt, err := createTaskWorktree(cfg, cwd, task)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Working in %s on branch %s; your checkout is untouched.\n", wt.Path, wt.Branch)
+		cwd = wt.Path
+	}
+	runAutonomousMode(cfg, cwd, task, nil)
+}
+
+// runAutonomousMode runs a task autonomously in workingDir, or resumes it
+// from cp if that is set.
+func runAutonomousMode(cfg *config.Config, workingDir, task string, cp *agent.Checkpoint) {
 	// Create agent loop
-	loop, err := agent.NewLoop(cfg)
+	loop, err := agent.NewLoopAt(cfg, workingDir)
 	if err != nil {
 		logger.ErrorF("Failed to create agent", map[string]interface{}{
 			"error": err.Error(),
@@ -484,6 +743,15 @@ func runAuto(args []string) {
 		fmt.Println("\nMake sure ANTHROPIC_API_KEY is set.")
 		os.Exit(1)
 	}
+	defer loop.Close()
+
+	// In a worktree made by --worktree, commit at each milestone
+	wt := git.OpenWorktree(workingDir)
+	if wt != nil {
+		loop.SetMilestoneHandler(func(summary string) {
+			commitWork(wt, os.Stdout, task, summary)
+		})
+	}
 
 	// Setup context with signal handling
 	ctx, cancel := context.WithCancel(context.Background())
@@ -495,14 +763,18 @@ func runAuto(args []string) {
 	// Handle Ctrl+C gracefully
 	go func() {
 		<-sigChan
-		fmt.Println("\n\n[Autonomous mode interrupted]")
+		fmt.Println("\n\n[Autonomous mode interrupted - run 'domiclaw auto --resume' to continue]")
 		loop.Stop()
+		loop.Close()
 		cancel()
 		os.Exit(0)
 	}()
 
 	// Print header
-	cwd, _ := os.Getwd()
+	start := "Starting autonomous execution..."
+	if cp != nil {
+		start = fmt.Sprintf("Resuming after %d cycles (%d tokens used)...", cp.Cycles, cp.Usage.Total())
+	}
 	fmt.Printf(`
 ╔══════════════════════════════════════════════════════════════════╗
 ║              DomiClaw Autonomous Mode                            ║
@@ -511,22 +783,44 @@ func runAuto(args []string) {
 Workspace: %s
 Task: %s
 
-Starting autonomous execution... (Ctrl+C to stop)
+%s (Ctrl+C to stop)
 
-`, cwd, task)
+`, workingDir, task, start)
 
 	// Run autonomous loop
-	if err := loop.RunAutonomous(ctx, task); err != nil {
+	var result *agent.AutonomousResult
+	if cp != nil {
+		result, err = loop.ResumeAutonomous(ctx, cp)
+	} else {
+		result, err = loop.RunAutonomous(ctx, task)
+	}
+	if err != nil {
 		if err == context.Canceled {
 			return
 		}
 		logger.ErrorF("Autonomous mode error", map[string]interface{}{
 			"error": err.Error(),
 		})
+		if wt != nil {
+			finishWorktree(wt, task, "failed")
+		}
 		os.Exit(1)
 	}
+	if wt != nil {
+		defer finishWorktree(wt, task, result.Status)
+	}
 
-	fmt.Println("\n[Autonomous mode completed]")
+	switch result.Status {
+	case "paused":
+		fmt.Printf("\n[Autonomous mode paused after %d cycles: %s]\n", result.Cycles, result.Reason)
+		fmt.Println("Run 'domiclaw auto --resume' to continue.")
+	case "stopped":
+		fmt.Println("\n[Autonomous mode stopped]")
+		fmt.Println("Run 'domiclaw auto --resume' to continue.")
+	default:
+		fmt.Println("\n[Autonomous mode completed]")
+		fmt.Print(agent.FormatVerification(result.Verification))
+	}
 }
 
 func boolToStatus(b bool) string {
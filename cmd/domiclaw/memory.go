@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/DomiYoung/domiclaw/pkg/agent"
+	"github.com/DomiYoung/domiclaw/pkg/config"
+	"github.com/DomiYoung/domiclaw/pkg/logger"
+	"github.com/DomiYoung/domiclaw/pkg/memory"
+	"github.com/DomiYoung/domiclaw/pkg/tools"
+)
+
+func runMemory(args []string) {
+	if len(args) == 0 {
+		printMemoryUsage()
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.ErrorF("Failed to load config", map[string]interface{}{
+			"error": err.Error(),
+		})
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "search":
+		runMemorySearch(cfg, args[1:])
+	case "consolidate":
+		runMemoryConsolidate(cfg, args[1:])
+	default:
+		fmt.Printf("Unknown memory command: %s\n\n", args[0])
+		printMemoryUsage()
+		os.Exit(1)
+	}
+}
+
+func printMemoryUsage() {
+	fmt.Println(`Usage: domiclaw memory <command>
+
+Commands:
+  search <query> [-n N]   Search long-term memory, daily notes and sessions
+  consolidate [--dry-run] [--keep-days N]
+                          Distill daily notes older than N days (default:
+                          memory.daily_notes_days) into MEMORY.md and
+                          monthly summaries, then archive them`)
+}
+
+func runMemorySearch(cfg *config.Config, args []string) {
+	limit := 10
+	var words []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-n", "--limit":
+			if i+1 < len(args) {
+				if n, err := strconv.Atoi(args[i+1]); err == nil && n > 0 {
+					limit = n
+				}
+				i++
+			}
+		default:
+			words = append(words, args[i])
+		}
+	}
+
+	query := strings.Join(words, " ")
+	if query == "" {
+		fmt.Println("Error: No query provided.")
+		fmt.Println("Usage: domiclaw memory search <query>")
+		os.Exit(1)
+	}
+
+	mem := memory.NewStore(cfg.WorkspacePath())
+	var project *memory.Store
+	if wd, err := os.Getwd(); err == nil {
+		if root := memory.FindProjectRoot(wd); root != "" {
+			project = memory.NewProjectStore(root)
+		}
+	}
+	idx := memory.BuildIndex(cfg.SessionsDir(), mem, project)
+	fmt.Printf("Searched %d documents.\n\n", idx.Len())
+	fmt.Println(tools.FormatMemoryHits(query, idx.Search(query, limit)))
+}
+
+func runMemoryConsolidate(cfg *config.Config, args []string) {
+	dryRun := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--dry-run":
+			dryRun = true
+		case "--keep-days":
+			if i+1 < len(args) {
+				if n, err := strconv.Atoi(args[i+1]); err == nil && n > 0 {
+					cfg.Memory.DailyNotesDays = n
+				}
+				i++
+			}
+		}
+	}
+
+	// A dry run doesn't call the model, so it works without an API key
+	var loop *agent.Loop
+	if !dryRun {
+		var err error
+		loop, err = agent.NewLoop(cfg)
+		if err != nil {
+			logger.ErrorF("Failed to create agent", map[string]interface{}{
+				"error": err.Error(),
+			})
+			os.Exit(1)
+		}
+		defer loop.Close()
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	result, err := consolidateMemory(ctx, cfg, loop, dryRun)
+	if result != nil {
+		if dryRun {
+			fmt.Printf("Would consolidate %d daily notes:\n", len(result.Notes))
+			for _, note := range result.Notes {
+				fmt.Printf("  %s\n", note)
+			}
+		} else {
+			fmt.Printf("Consolidated %d daily notes into %d monthly summaries, %d new long-term memories.\n",
+				len(result.Notes), len(result.Months), result.Facts)
+		}
+	}
+	if err != nil {
+		logger.ErrorF("Consolidation failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		os.Exit(1)
+	}
+}
+
+// consolidateMemory distills daily notes older than memory.daily_notes_days
+// using the loop's model. loop may be nil for a dry run.
+func consolidateMemory(ctx context.Context, cfg *config.Config, loop *agent.Loop, dryRun bool) (*memory.ConsolidateResult, error) {
+	c := &memory.Consolidator{
+		Store:    memory.NewStore(cfg.WorkspacePath()),
+		KeepDays: cfg.Memory.DailyNotesDays,
+		DryRun:   dryRun,
+	}
+	if loop != nil {
+		c.Summarize = loop.Ask
+	}
+	return c.Run(ctx)
+}
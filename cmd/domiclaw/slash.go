@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/DomiYoung/domiclaw/pkg/commands"
+	"github.com/DomiYoung/domiclaw/pkg/config"
+	"github.com/DomiYoung/domiclaw/pkg/memory"
+)
+
+// chatBuiltins are the chat commands handled by runChat, in /help order.
+var chatBuiltins = []struct{ name, description string }{
+	{"help", "List commands, including custom ones"},
+	{"clear", "Clear conversation history"},
+	{"status", "Show status"},
+	{"todo", "Show the agent's todo list"},
+	{"plan", "Plan mode: read-only tools, the agent proposes a plan"},
+	{"approve", "Approve the plan and execute it with all tools"},
+	{"quit", "Exit chat (also /exit)"},
+}
+
+// isChatBuiltin reports whether name is handled by runChat itself.
+func isChatBuiltin(name string) bool {
+	switch name {
+	case "quit", "exit", "q", "todos":
+		return true
+	}
+	for _, b := range chatBuiltins {
+		if b.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// projectCommandsDir returns the .domiclaw/commands directory of the
+// project containing dir, or of dir itself outside a repository.
+func projectCommandsDir(dir string) string {
+	root := memory.FindProjectRoot(dir)
+	if root == "" {
+		root = dir
+	}
+	return filepath.Join(root, ".domiclaw", "commands")
+}
+
+// loadSlashCommands loads the user's and the project's custom commands.
+func loadSlashCommands(dir string) ([]*commands.Command, error) {
+	return commands.Load(config.CommandsDir(), projectCommandsDir(dir))
+}
+
+// parseSlash splits "/name args" into the lowercased name and the args.
+func parseSlash(input string) (string, string) {
+	name, args, _ := strings.Cut(strings.TrimPrefix(input, "/"), " ")
+	return strings.ToLower(name), strings.TrimSpace(args)
+}
+
+// expandSlash expands input if it invokes a custom command. It returns the
+// prompt and the command's tool list; ok is false if input doesn't name a
+// custom command.
+func expandSlash(dir, input string) (prompt string, allowed []string, ok bool, err error) {
+	if !strings.HasPrefix(input, "/") {
+		return input, nil, false, nil
+	}
+	cmds, err := loadSlashCommands(dir)
+	if err != nil {
+		return "", nil, false, err
+	}
+	name, args := parseSlash(input)
+	cmd := commands.Find(cmds, name)
+	if cmd == nil || isChatBuiltin(name) {
+		return input, nil, false, nil
+	}
+	return cmd.Expand(args), cmd.Tools, true, nil
+}
+
+// printChatHelp lists the built-in chat commands and the custom ones in
+// dir's project and the user's commands directory.
+func printChatHelp(dir string) {
+	fmt.Println("Commands:")
+	for _, b := range chatBuiltins {
+		fmt.Printf("  /%-12s %s\n", b.name, b.description)
+	}
+
+	cmds, err := loadSlashCommands(dir)
+	if err != nil {
+		fmt.Printf("\nError loading custom commands: %v\n", err)
+		return
+	}
+	if len(cmds) == 0 {
+		fmt.Printf("\nNo custom commands. Add markdown prompt templates to %s\nor %s.\n",
+			config.CommandsDir(), projectCommandsDir(dir))
+		return
+	}
+
+	fmt.Println("\nCustom commands:")
+	for _, cmd := range cmds {
+		desc := cmd.Description
+		if len(cmd.Tools) > 0 {
+			desc += " [tools: " + strings.Join(cmd.Tools, ", ") + "]"
+		}
+		if isChatBuiltin(cmd.Name) {
+			desc += " (hidden by the built-in command)"
+		}
+		fmt.Printf("  /%-12s %s (%s)\n", cmd.Name, desc, cmd.Scope)
+	}
+}
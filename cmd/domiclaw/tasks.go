@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/agent"
+	"github.com/DomiYoung/domiclaw/pkg/config"
+	"github.com/DomiYoung/domiclaw/pkg/logger"
+	"github.com/DomiYoung/domiclaw/pkg/tasks"
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// taskPollInterval is how often an idle worker checks the queue, and how
+// often a running task is checked for cancellation.
+const taskPollInterval = 3 * time.Second
+
+func runTasks(args []string) {
+	if len(args) == 0 {
+		printTasksUsage()
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.ErrorF("Failed to load config", map[string]interface{}{
+			"error": err.Error(),
+		})
+		os.Exit(1)
+	}
+	queue := tasks.NewQueue(cfg.TasksDir())
+
+	switch args[0] {
+	case "add":
+		runTasksAdd(queue, args[1:])
+	case "list", "ls":
+		runTasksList(queue)
+	case "cancel":
+		runTasksUpdate(args[1:], "cancel", queue.Cancel)
+	case "resume":
+		runTasksUpdate(args[1:], "resume", queue.Resume)
+	case "logs", "log":
+		runTasksLogs(queue, args[1:])
+	case "work":
+		runTasksWork(cfg, queue, args[1:])
+	default:
+		fmt.Printf("Unknown tasks command: %s\n\n", args[0])
+		printTasksUsage()
+		os.Exit(1)
+	}
+}
+
+func printTasksUsage() {
+	fmt.Println(`Usage: domiclaw tasks <command>
+
+Commands:
+  add [-w dir] <task>   Queue an autonomous task (runs in dir, default: here)
+  list                  List tasks with status and cycles used
+  cancel <id>           Cancel a queued, paused or running task
+  resume <id>           Queue a paused or failed task again
+  logs <id>             Show a task's final message and output log
+  work [--once]         Run queued tasks one at a time (--once: until empty)`)
+}
+
+func runTasksAdd(queue *tasks.Queue, args []string) {
+	workingDir, _ := os.Getwd()
+	var words []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-w", "--workspace":
+			if i+1 < len(args) {
+				workingDir = utils.ExpandPath(args[i+1])
+				i++
+			}
+		default:
+			words = append(words, args[i])
+		}
+	}
+
+	description := strings.TrimSpace(strings.Join(words, " "))
+	if description == "" {
+		fmt.Println("Error: No task provided.")
+		fmt.Println("Usage: domiclaw tasks add [-w dir] \"your task description\"")
+		os.Exit(1)
+	}
+	if abs, err := filepath.Abs(workingDir); err == nil {
+		workingDir = abs
+	}
+
+	task, err := queue.Add(description, workingDir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Queued task %s in %s\n", task.ID, task.WorkingDir)
+	fmt.Println("Run 'domiclaw tasks work' to process the queue.")
+}
+
+func runTasksList(queue *tasks.Queue) {
+	all, err := queue.List()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(all) == 0 {
+		fmt.Println("No tasks. Add one with 'domiclaw tasks add \"...\"'.")
+		return
+	}
+
+	fmt.Printf("%-4s  %-9s  %6s  %-16s  %s\n", "ID", "STATUS", "CYCLES", "CREATED", "TASK")
+	for _, t := range all {
+		fmt.Printf("%-4s  %-9s  %6d  %-16s  %s\n",
+			t.ID, t.Status, t.Cycles, t.Created.Format("2006-01-02 15:04"), utils.Truncate(oneLineText(t.Description), 60))
+	}
+}
+
+func runTasksUpdate(args []string, verb string, update func(id string) (*tasks.Task, error)) {
+	if len(args) == 0 {
+		fmt.Printf("Usage: domiclaw tasks %s <id>\n", verb)
+		os.Exit(1)
+	}
+	task, err := update(args[0])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Task %s is now %s.\n", task.ID, task.Status)
+}
+
+func runTasksLogs(queue *tasks.Queue, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: domiclaw tasks logs <id>")
+		os.Exit(1)
+	}
+	task, err := queue.Get(args[0])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Task %s: %s\n", task.ID, task.Description)
+	fmt.Printf("Status:   %s (attempts: %d, cycles: %d)\n", task.Status, task.Attempts, task.Cycles)
+	fmt.Printf("Dir:      %s\n", task.WorkingDir)
+	if task.Error != "" {
+		fmt.Printf("Error:    %s\n", task.Error)
+	}
+	if task.Message != "" {
+		fmt.Printf("\nFinal message:\n%s\n", task.Message)
+	}
+
+	if log := utils.ReadFileString(queue.LogPath(task.ID)); log != "" {
+		fmt.Printf("\n--- Output (%s) ---\n%s", queue.LogPath(task.ID), log)
+	}
+}
+
+func runTasksWork(cfg *config.Config, queue *tasks.Queue, args []string) {
+	once := false
+	for _, arg := range args {
+		if arg == "--once" {
+			once = true
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	fmt.Println("DomiClaw task worker started. Press Ctrl+C to stop.")
+	recovered, err := queue.RecoverStale()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, task := range recovered {
+		fmt.Printf("Task %s was left running by a worker that exited; it is now paused (resume it with 'domiclaw tasks resume %s').\n", task.ID, task.ID)
+	}
+
+	for {
+		task, runLock, err := queue.Claim()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if task == nil {
+			if once {
+				fmt.Println("Queue is empty.")
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(taskPollInterval):
+				continue
+			}
+		}
+
+		runQueuedTask(ctx, cfg, queue, task)
+		runLock.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// runQueuedTask runs a claimed task with RunAutonomous in a fresh agent
+// loop, teeing its output to the task log, and records the outcome.
+func runQueuedTask(ctx context.Context, cfg *config.Config, queue *tasks.Queue, task *tasks.Task) {
+	fmt.Printf("\n=== Task %s (attempt %d): %s ===\n", task.ID, task.Attempts, utils.Truncate(oneLineText(task.Description), 60))
+
+	logFile, err := os.OpenFile(queue.LogPath(task.ID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		queue.Finish(task.ID, tasks.StatusFailed, 0, "", err.Error())
+		return
+	}
+	defer logFile.Close()
+	out := io.MultiWriter(os.Stdout, logFile)
+	fmt.Fprintf(logFile, "\n=== Attempt %d, %s ===\n", task.Attempts, time.Now().Format("2006-01-02 15:04:05"))
+
+	loop, err := agent.NewLoopAt(cfg, task.WorkingDir)
+	if err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+		queue.Finish(task.ID, tasks.StatusFailed, 0, "", err.Error())
+		return
+	}
+	defer loop.Close()
+	loop.SetOutput(out)
+	loop.SetCheckpointPath(queue.CheckpointPath(task.ID))
+
+	// Stop the run if the task is canceled meanwhile
+	taskCtx, stopTask := context.WithCancel(ctx)
+	defer stopTask()
+	go func() {
+		ticker := time.NewTicker(taskPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-taskCtx.Done():
+				return
+			case <-ticker.C:
+				if t, err := queue.Get(task.ID); err == nil && t.Status == tasks.StatusCanceled {
+					fmt.Fprintf(out, "\n[Task %s canceled]\n", task.ID)
+					loop.Stop()
+					stopTask()
+					return
+				}
+			}
+		}
+	}()
+
+	// A resumed task picks up from its checkpoint when it has one
+	var result *agent.AutonomousResult
+	cp, cperr := agent.LoadCheckpoint(queue.CheckpointPath(task.ID))
+	if cperr != nil {
+		fmt.Fprintf(out, "Ignoring checkpoint: %v\n", cperr)
+	}
+	if task.Attempts > 1 && cp != nil {
+		result, err = loop.ResumeAutonomous(taskCtx, cp)
+	} else {
+		result, err = loop.RunAutonomous(taskCtx, taskPrompt(task))
+	}
+
+	status, errMsg := tasks.StatusComplete, ""
+	switch {
+	case ctx.Err() != nil:
+		// The worker is shutting down; leave the task resumable
+		status, errMsg = tasks.StatusPaused, "interrupted: worker stopped"
+	case err != nil && !errors.Is(err, context.Canceled):
+		status, errMsg = tasks.StatusFailed, err.Error()
+	case result.Status == "paused":
+		status = tasks.StatusPaused
+	case result.Status == "stopped":
+		status = tasks.StatusCanceled
+	}
+
+	finished, ferr := queue.Finish(task.ID, status, result.Cycles, result.Message, errMsg)
+	if ferr != nil {
+		fmt.Printf("Error: failed to record task %s: %v\n", task.ID, ferr)
+		return
+	}
+	reason := ""
+	if result.Reason != "" && finished.Status == tasks.StatusPaused {
+		reason = " (" + result.Reason + ")"
+	}
+	fmt.Fprintf(out, "\n=== Task %s %s after %d cycles%s ===\n", finished.ID, finished.Status, result.Cycles, reason)
+	if finished.Status == tasks.StatusComplete {
+		fmt.Fprint(out, agent.FormatVerification(result.Verification))
+	}
+}
+
+// taskPrompt returns the prompt for a task, with the previous final message
+// when resuming a paused task.
+func taskPrompt(task *tasks.Task) string {
+	if task.Attempts <= 1 || task.Message == "" {
+		return task.Description
+	}
+	return fmt.Sprintf(`%s
+
+This task was started before and paused. Your last message was:
+
+%s
+
+Check the current state of the work, then continue from where you left off.`, task.Description, task.Message)
+}
+
+// oneLineText collapses whitespace, including newlines, to single spaces.
+func oneLineText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/config"
+	"github.com/DomiYoung/domiclaw/pkg/git"
+	"github.com/DomiYoung/domiclaw/pkg/logger"
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// createTaskWorktree creates a worktree for an autonomous task on a new
+// branch off the current HEAD of the repository containing dir.
+func createTaskWorktree(cfg *config.Config, dir, task string) (*git.Worktree, error) {
+	root, err := git.RepoRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("--worktree needs a git repository: %w", err)
+	}
+
+	// Add a suffix when the same task was started within the minute
+	base := git.BranchName(task, time.Now())
+	branch, path := "", ""
+	for i := 1; ; i++ {
+		branch = base
+		if i > 1 {
+			branch = fmt.Sprintf("%s-%d", base, i)
+		}
+		name := filepath.Base(root) + "-" + strings.TrimPrefix(branch, git.BranchPrefix)
+		path = filepath.Join(cfg.WorktreesDir(), name)
+		if !utils.FileExists(path) && !git.BranchExists(dir, branch) {
+			break
+		}
+	}
+	if err := utils.EnsureDir(cfg.WorktreesDir()); err != nil {
+		return nil, err
+	}
+	wt, err := git.CreateWorktree(dir, path, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run in the same subdirectory of the repository as the user
+	if rel, err := filepath.Rel(root, dir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		if sub := filepath.Join(wt.Path, rel); utils.FileExists(sub) {
+			wt.Path = sub
+		}
+	}
+	return wt, nil
+}
+
+// commitWork commits everything in the worktree with a message generated
+// from summary, and reports the commit on out.
+func commitWork(wt *git.Worktree, out io.Writer, task, summary string) {
+	subject := utils.Truncate(strings.Join(strings.Fields(summary), " "), 72)
+	message := fmt.Sprintf("%s\n\nAutonomous task: %s\n", subject, task)
+
+	committed, err := wt.Commit(message)
+	if err != nil {
+		logger.WarnCF("auto", "Failed to commit milestone", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if committed {
+		fmt.Fprintf(out, "\n[commit] %s\n", subject)
+	}
+}
+
+// finishWorktree commits what is left of an autonomous run and prints the
+// branch, its commits and a diff summary.
+func finishWorktree(wt *git.Worktree, task, status string) {
+	summary := "Complete: " + task
+	if status != "complete" {
+		summary = fmt.Sprintf("WIP (%s): %s", status, task)
+	}
+	commitWork(wt, os.Stdout, task, summary)
+
+	root, err := git.RepoRoot(wt.Path)
+	if err != nil {
+		root = wt.Path
+	}
+	fmt.Printf("\nBranch:   %s\n", wt.Branch)
+	fmt.Printf("Worktree: %s\n", root)
+
+	if log, err := wt.Log(); err == nil && log != "" {
+		fmt.Printf("\nCommits:\n%s\n", indent(log))
+	}
+	if stat, err := wt.DiffStat(); err == nil && stat != "" {
+		fmt.Printf("\nChanges:\n%s\n", indent(stat))
+	} else {
+		fmt.Println("\nNo changes.")
+	}
+
+	fmt.Printf("\nMerge:    git merge %s\n", wt.Branch)
+	fmt.Printf("Discard:  git worktree remove --force %s && git branch -D %s\n", root, wt.Branch)
+}
+
+// indent indents each line by two spaces.
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
+}
@@ -0,0 +1,230 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/providers"
+	"github.com/DomiYoung/domiclaw/pkg/tools"
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// Checkpoint is the saved state of an autonomous run, written after every
+// cycle so the run can be resumed exactly where it stopped.
+type Checkpoint struct {
+	Task         string              `json:"task"`
+	WorkingDir   string              `json:"working_dir"`
+	Status       string              `json:"status"`           // "running", "paused", "stopped" or "context_overflow"
+	Reason       string              `json:"reason,omitempty"` // Why the run stopped, if it did
+	Cycles       int                 `json:"cycles"`           // Cycles run, over all resumes
+	Plan         string              `json:"plan,omitempty"`   // The agent's latest plan
+	Milestones   []Milestone         `json:"milestones,omitempty"`
+	Todos        []tools.TodoItem    `json:"todos,omitempty"`
+	Verification []VerifyResult      `json:"verification,omitempty"` // Latest verification run
+	Usage        TokenUsage          `json:"usage"`                  // Tokens used, over all resumes
+	Messages     []providers.Message `json:"messages"`
+	Started      time.Time           `json:"started"`
+	Updated      time.Time           `json:"updated"`
+}
+
+// Milestone is a progress marker the agent reported, such as "Phase
+// complete".
+type Milestone struct {
+	Cycle int       `json:"cycle"`
+	Text  string    `json:"text"`
+	Time  time.Time `json:"time"`
+}
+
+// TokenUsage counts tokens sent to and received from the model.
+type TokenUsage struct {
+	Input  int `json:"input"`
+	Output int `json:"output"`
+}
+
+// Total returns the input and output tokens combined.
+func (u TokenUsage) Total() int {
+	return u.Input + u.Output
+}
+
+// LoadCheckpoint reads a checkpoint. It returns nil and no error if there
+// is none.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", path, err)
+	}
+	if cp.Task == "" || len(cp.Messages) == 0 {
+		return nil, fmt.Errorf("checkpoint %s is incomplete", path)
+	}
+	return &cp, nil
+}
+
+// save writes the checkpoint atomically, so a crash mid-write leaves the
+// previous one intact.
+func (cp *Checkpoint) save(path string) error {
+	cp.Updated = time.Now()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, data, 0644)
+}
+
+// maxMilestones bounds the milestones kept in a checkpoint.
+const maxMilestones = 50
+
+// planItemPattern matches numbered and checklist items.
+var planItemPattern = regexp.MustCompile(`(?m)^\s*(\d+[.)]|[-*] \[[ xX]\])\s+\S`)
+
+// track records the plan and milestones found in an assistant message and
+// returns the new milestones.
+func (cp *Checkpoint) track(content string, boundaryPatterns []string) []Milestone {
+	if strings.Contains(strings.ToLower(content), "plan") && len(planItemPattern.FindAllString(content, -1)) >= 2 {
+		cp.Plan = strings.TrimSpace(content)
+	}
+
+	var added []Milestone
+	for _, line := range strings.Split(content, "\n") {
+		for _, pattern := range boundaryPatterns {
+			if strings.Contains(line, pattern) {
+				added = append(added, Milestone{
+					Cycle: cp.Cycles,
+					Text:  utils.Truncate(strings.TrimSpace(line), 200),
+					Time:  time.Now(),
+				})
+				break
+			}
+		}
+	}
+
+	cp.Milestones = append(cp.Milestones, added...)
+	if len(cp.Milestones) > maxMilestones {
+		cp.Milestones = cp.Milestones[len(cp.Milestones)-maxMilestones:]
+	}
+	return added
+}
+
+// progressSummary describes the plan and milestones, for the model when
+// history has been compacted.
+func (cp *Checkpoint) progressSummary() string {
+	var sb strings.Builder
+	if cp.Plan != "" {
+		sb.WriteString("### Plan\n\n" + cp.Plan + "\n\n")
+	}
+	if len(cp.Milestones) > 0 {
+		sb.WriteString("### Milestones\n\n")
+		for _, m := range cp.Milestones {
+			fmt.Fprintf(&sb, "- Cycle %d: %s\n", m.Cycle, m.Text)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+const (
+	// compactKeepMessages is how many recent messages survive compaction.
+	compactKeepMessages = 20
+	// compactMaxMessageChars bounds each kept message after compaction.
+	compactMaxMessageChars = 8000
+	// compactTranscriptChars bounds the transcript sent for summarizing.
+	compactTranscriptChars = 60000
+)
+
+// estimateTokens roughly estimates the tokens in a message history.
+func estimateTokens(messages []providers.Message) int {
+	chars := 0
+	for _, msg := range messages {
+		chars += len(msg.Content)
+		for _, tc := range msg.ToolCalls {
+			chars += len(marshalArgs(tc.Arguments))
+		}
+	}
+	return chars / 4
+}
+
+// needsCompaction reports whether a restored history should be compacted
+// before it is sent to the model again.
+func (l *Loop) needsCompaction(cp *Checkpoint) bool {
+	if cp.Status == "context_overflow" {
+		return true
+	}
+	window := l.cfg.Agents.ContextWindow
+	threshold := l.cfg.Memory.AutoSummarizeThreshold
+	if window <= 0 || threshold <= 0 {
+		return false
+	}
+	return float64(estimateTokens(cp.Messages)) > threshold*float64(window)
+}
+
+// compactCheckpoint replaces the older part of the history with a summary,
+// keeping the system prompt, the task and the most recent messages.
+func (l *Loop) compactCheckpoint(ctx context.Context, cp *Checkpoint) {
+	msgs := cp.Messages
+	if len(msgs) <= 2+compactKeepMessages {
+		// Nothing old to drop; just trim oversized messages
+		for i := 2; i < len(msgs); i++ {
+			msgs[i].Content = utils.Truncate(msgs[i].Content, compactMaxMessageChars)
+		}
+		return
+	}
+
+	// Don't start the kept part with tool results whose call was dropped
+	start := len(msgs) - compactKeepMessages
+	for start < len(msgs) && msgs[start].Role == "tool" {
+		start++
+	}
+	dropped := msgs[2:start]
+
+	summary, err := l.summarizeMessages(ctx, cp.Task, dropped)
+	if err != nil {
+		summary = fmt.Sprintf("(%d earlier messages were dropped; summarizing them failed: %v)", len(dropped), err)
+	}
+
+	task := msgs[1]
+	task.Content += fmt.Sprintf("\n\n---\n\n## Progress So Far\n\nThis run was resumed from a checkpoint after %d cycles and its history was compacted.\n\n%s### Summary of Earlier Work\n\n%s",
+		cp.Cycles, cp.progressSummary(), summary)
+
+	compacted := []providers.Message{msgs[0], task}
+	for _, msg := range msgs[start:] {
+		msg.Content = utils.Truncate(msg.Content, compactMaxMessageChars)
+		compacted = append(compacted, msg)
+	}
+	cp.Messages = compacted
+}
+
+// summarizeMessages asks the model to summarize part of a run's history.
+func (l *Loop) summarizeMessages(ctx context.Context, task string, messages []providers.Message) (string, error) {
+	var sb strings.Builder
+	for _, msg := range messages {
+		if sb.Len() > compactTranscriptChars {
+			sb.WriteString("\n[... transcript truncated ...]\n")
+			break
+		}
+		fmt.Fprintf(&sb, "[%s] %s\n", msg.Role, utils.Truncate(msg.Content, 500))
+		for _, tc := range msg.ToolCalls {
+			fmt.Fprintf(&sb, "[tool call] %s %s\n", tc.Name, utils.Truncate(marshalArgs(tc.Arguments), 200))
+		}
+	}
+
+	return l.Ask(ctx, fmt.Sprintf(`Below is part of the transcript of an autonomous coding run working on this task:
+
+%s
+
+Summarize what was done: files created or changed, commands run and their outcomes, decisions made, problems found and whether they were fixed, and what remained to do. Be specific and concise; use a bulleted list.
+
+---
+
+%s`, task, sb.String()))
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DomiYoung/domiclaw/pkg/hooks"
+	"github.com/DomiYoung/domiclaw/pkg/logger"
+	"github.com/DomiYoung/domiclaw/pkg/providers"
+	"github.com/DomiYoung/domiclaw/pkg/tools"
+)
+
+// executeTool runs a tool call from registry between the pre_tool and
+// post_tool hooks. A failed or blocked call returns its error along with
+// an "Error: ..." result for the model; component labels the log lines.
+func (l *Loop) executeTool(ctx context.Context, registry *tools.Registry, tc providers.ToolCall, component string) (string, error) {
+	name := registry.ResolveName(tc.Name)
+	payload := hooks.Payload{Tool: name, Input: tc.Arguments}
+
+	payload.Event = hooks.PreTool
+	if pre := l.hooks.Run(ctx, payload); pre.Blocked {
+		logger.InfoCF(component, "Tool call blocked by hook", map[string]interface{}{
+			"tool":   name,
+			"reason": pre.Feedback,
+		})
+		err := fmt.Errorf("blocked by pre_tool hook: %s", pre.Feedback)
+		return fmt.Sprintf("Error: %v", err), err
+	}
+
+	result, err := registry.Execute(ctx, tc.Name, tc.Arguments)
+	if err != nil {
+		result = fmt.Sprintf("Error: %v", err)
+		logger.WarnCF(component, "Tool execution failed", map[string]interface{}{
+			"tool":  name,
+			"error": err.Error(),
+		})
+	}
+
+	payload.Event = hooks.PostTool
+	payload.Output = result
+	payload.IsError = err != nil
+	if post := l.hooks.Run(ctx, payload); post.Blocked {
+		result += "\n\nA post_tool hook reported a problem:\n" + post.Feedback
+	} else if post.Feedback != "" {
+		result += "\n\nHook output:\n" + post.Feedback
+	}
+	return result, err
+}
+
+// submitPrompt runs the user_prompt_submit hooks on prompt. It returns the
+// prompt with any context the hooks added, or an error if a hook blocked it.
+func (l *Loop) submitPrompt(ctx context.Context, prompt string) (string, error) {
+	res := l.hooks.Run(ctx, hooks.Payload{Event: hooks.UserPromptSubmit, Prompt: prompt})
+	if res.Blocked {
+		return "", fmt.Errorf("prompt blocked by hook: %s", res.Feedback)
+	}
+	if res.Feedback != "" {
+		prompt += "\n\n" + res.Feedback
+	}
+	return prompt, nil
+}
+
+// endTurn runs the turn_end hooks on the agent's reply. If a hook blocks,
+// it returns a message asking the agent to continue with the hook's
+// feedback.
+func (l *Loop) endTurn(ctx context.Context, reply string) (string, bool) {
+	res := l.hooks.Run(ctx, hooks.Payload{Event: hooks.TurnEnd, Reply: reply})
+	if !res.Blocked {
+		return "", false
+	}
+	fmt.Fprintf(l.out, "\n[turn_end hook: continuing]\n")
+	return "A turn_end hook asked you to keep going:\n\n" + res.Feedback, true
+}
+
+// EndSession runs the session_end hooks, e.g. to send a notification.
+// status says how the session ended, e.g. "done" or "complete", and prompt
+// is the session's first prompt or task, if any. The hooks run even when
+// the session was interrupted.
+func (l *Loop) EndSession(status, prompt string) {
+	l.hooks.Run(context.Background(), hooks.Payload{
+		Event:  hooks.SessionEnd,
+		Status: status,
+		Prompt: prompt,
+		Reply:  l.lastAssistantMessage(),
+	})
+}
@@ -4,13 +4,16 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/DomiYoung/domiclaw/pkg/config"
+	"github.com/DomiYoung/domiclaw/pkg/hooks"
 	"github.com/DomiYoung/domiclaw/pkg/logger"
 	"github.com/DomiYoung/domiclaw/pkg/memory"
 	"github.com/DomiYoung/domiclaw/pkg/providers"
@@ -24,47 +27,126 @@ type Loop struct {
 	cfg      *config.Config
 	provider providers.Provider
 	memory   *memory.Store
+	project  *memory.Store // Memory of the repository being worked on, if any
 	sessions *session.Manager
 	tools    *tools.Registry
+	exec     *tools.ExecTool
+	hooks    *hooks.Runner
+	todos    *tools.TodoList // The agent's plan for the current session
+	planMode bool            // Offer only read-only tools until a plan is approved
+	allowed  []string        // Tools offered for the next turns, e.g. by a slash command; nil for all
 
 	// For interactive mode: persistent message history
 	messages []providers.Message
 	toolDefs []providers.ToolDefinition
 
+	sessionID    string // Transcript the history is saved to, for memory_search
+	sessionSaved int    // Messages of the history already in the transcript
+
+	out io.Writer // Streamed model output and tool progress; os.Stdout by default
+
+	checkpointPath string        // Where autonomous runs save their state
+	onMilestone    MilestoneFunc // Optional; called at autonomous milestones
+
 	running  bool
 	mu       sync.Mutex
 	stopChan chan struct{}
+
+	subAgents    map[int]context.CancelFunc // Running sub-agents, for Stop
+	nextSubAgent int
+}
+
+// AutonomousResult reports how an autonomous run ended.
+type AutonomousResult struct {
+	Status  string // "complete", "paused" or "stopped"
+	Reason  string // Why the run paused, e.g. a budget was reached
+	Cycles  int    // Cycles run
+	Message string // The agent's last message
+
+	Verification []VerifyResult // Checks run when the agent last claimed completion
 }
 
+// MilestoneFunc is called when an autonomous run reaches a milestone: a
+// todo item was completed or the agent reported a strategic boundary.
+// summary describes it in a line, e.g. "Add login form validation".
+type MilestoneFunc func(summary string)
+
+// errTaskPaused is returned by runAutonomousCycle when the agent pauses.
+var errTaskPaused = errors.New("task paused by agent")
+
 // NewLoop creates a new agent loop.
 func NewLoop(cfg *config.Config) (*Loop, error) {
+	// Determine working directory for command execution
+	// Use current working directory (where user ran domiclaw), not the internal workspace
+	workingDir, err := os.Getwd()
+	if err != nil {
+		workingDir = cfg.WorkspacePath()
+	}
+	return NewLoopAt(cfg, workingDir)
+}
+
+// NewLoopAt creates a new agent loop whose tools work in workingDir.
+func NewLoopAt(cfg *config.Config, workingDir string) (*Loop, error) {
 	// Create provider based on config
 	provider, err := createProvider(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	// Determine working directory for command execution
-	// Use current working directory (where user ran domiclaw), not the internal workspace
-	workingDir, err := os.Getwd()
-	if err != nil {
-		workingDir = cfg.WorkspacePath()
+	memStore := memory.NewStore(cfg.WorkspacePath())
+	var projectStore *memory.Store
+	if root := memory.FindProjectRoot(workingDir); root != "" {
+		projectStore = memory.NewProjectStore(root)
 	}
 
 	// Create tool registry with all available tools
 	toolRegistry := tools.NewRegistry()
-	toolRegistry.Register(&tools.ReadFileTool{})
+	toolRegistry.Register(&tools.ReadFileTool{
+		Workspace: workingDir,
+		// The internal workspace holds memory files and spilled tool output
+		AllowedDirs:  []string{cfg.WorkspacePath()},
+		MaxBytes:     cfg.Tools.ReadFile.MaxSizeBytes,
+		DefaultLimit: cfg.Tools.ReadFile.DefaultLimit,
+	})
 	toolRegistry.Register(&tools.WriteFileTool{Workspace: workingDir})
-	toolRegistry.Register(&tools.ListDirTool{})
+	toolRegistry.Register(&tools.ListDirTool{Workspace: workingDir})
 	toolRegistry.Register(&tools.EditFileTool{Workspace: workingDir})
 	toolRegistry.Register(&tools.GlobTool{Workspace: workingDir})
 	toolRegistry.Register(&tools.GrepTool{Workspace: workingDir})
-	toolRegistry.Register(tools.NewExecTool(workingDir))
+	execTool := newExecTool(cfg, workingDir)
+	registerExecTools(toolRegistry, execTool)
+	toolRegistry.Register(&tools.RememberTool{Store: memStore, Project: projectStore})
+	toolRegistry.Register(&tools.NoteTool{Store: memStore, Project: projectStore})
+	toolRegistry.Register(&tools.RecallTool{Store: memStore, Project: projectStore})
+	toolRegistry.Register(&tools.ForgetTool{Store: memStore, Project: projectStore})
+	toolRegistry.Register(&tools.MemorySearchTool{Store: memStore, Project: projectStore, SessionsDir: cfg.SessionsDir()})
+	todoList := tools.NewTodoList()
+	toolRegistry.Register(&tools.TodoTool{List: todoList})
+
+	// Register web search if a backend is configured
+	if backends := newSearchBackends(cfg); len(backends) > 0 {
+		searchTool := tools.NewWebSearchTool(cfg.Tools.Web.Search.MaxResults, backends...)
+		searchTool.CacheTTL = time.Duration(cfg.Tools.Web.Search.CacheTTLSeconds) * time.Second
+		toolRegistry.Register(searchTool)
+	}
 
-	// Register web search if API key available
-	if searchKey := cfg.GetSearchAPIKey(); searchKey != "" {
-		toolRegistry.Register(tools.NewWebSearchTool(searchKey, cfg.Tools.Web.Search.MaxResults))
+	fetchCfg := cfg.Tools.Web.Fetch
+	fetchTool := tools.NewWebFetchTool()
+	fetchTool.AllowDomains = fetchCfg.AllowDomains
+	fetchTool.DenyDomains = fetchCfg.DenyDomains
+	if fetchCfg.MaxBytes > 0 {
+		fetchTool.MaxBytes = fetchCfg.MaxBytes
 	}
+	if fetchCfg.TimeoutSeconds > 0 {
+		fetchTool.Timeout = time.Duration(fetchCfg.TimeoutSeconds) * time.Second
+	}
+	if fetchCfg.MaxRedirects > 0 {
+		fetchTool.MaxRedirects = fetchCfg.MaxRedirects
+	}
+	toolRegistry.Register(fetchTool)
+
+	// Bound tool results so one verbose command can't blow the context
+	toolRegistry.SetOutputLimiter(newOutputLimiter(cfg))
 
 	// Register aliases for Claude model compatibility
 	// Claude models are trained with specific tool names from Claude Code
@@ -77,15 +159,117 @@ func NewLoop(cfg *config.Config) (*Loop, error) {
 	toolRegistry.RegisterAlias("Grep", "grep")
 	toolRegistry.RegisterAlias("LS", "list_dir")
 	toolRegistry.RegisterAlias("WebSearch", "web_search")
+	toolRegistry.RegisterAlias("WebFetch", "web_fetch")
 
-	return &Loop{
+	l := &Loop{
 		cfg:      cfg,
 		provider: provider,
-		memory:   memory.NewStore(cfg.WorkspacePath()),
+		memory:   memStore,
+		project:  projectStore,
 		sessions: session.NewManager(cfg.SessionsDir()),
 		tools:    toolRegistry,
+		exec:     execTool,
+		hooks:    hooks.NewRunner(cfg.Hooks, workingDir),
+		todos:    todoList,
+		out:      os.Stdout,
 		stopChan: make(chan struct{}),
-	}, nil
+
+		checkpointPath: cfg.CheckpointPath(),
+	}
+	if err := l.SetSandboxProfile("interactive"); err != nil {
+		l.Close()
+		return nil, err
+	}
+	toolRegistry.Register(&tools.DelegateTool{
+		Spawn:         l.spawnSubAgent,
+		MaxConcurrent: cfg.Agents.MaxSubagents,
+	})
+
+	return l, nil
+}
+
+// newExecTool creates an exec tool working in workingDir, with its own
+// shell session and background processes.
+func newExecTool(cfg *config.Config, workingDir string) *tools.ExecTool {
+	execTool := tools.NewExecTool(workingDir)
+	if cfg.Tools.Exec.PersistentShell {
+		if shell := tools.NewShellSession(workingDir); shell.Available() {
+			execTool.Shell = shell
+		}
+	}
+	execTool.Processes = tools.NewProcessManager()
+	return execTool
+}
+
+// registerExecTools registers execTool and the tools managing its
+// background processes.
+func registerExecTools(registry *tools.Registry, execTool *tools.ExecTool) {
+	registry.Register(execTool)
+	registry.Register(&tools.ProcessOutputTool{Manager: execTool.Processes})
+	registry.Register(&tools.ProcessInputTool{Manager: execTool.Processes})
+	registry.Register(&tools.ProcessListTool{Manager: execTool.Processes})
+	registry.Register(&tools.ProcessKillTool{Manager: execTool.Processes})
+}
+
+// newOutputLimiter builds the tool output limiter from config.
+func newOutputLimiter(cfg *config.Config) *tools.OutputLimiter {
+	out := cfg.Tools.Output
+	limiter := &tools.OutputLimiter{
+		SpillDir: cfg.ToolOutputDir(),
+		Default:  tools.OutputLimit{MaxLines: out.MaxLines, MaxBytes: out.MaxBytes},
+		PerTool:  make(map[string]tools.OutputLimit),
+	}
+	for name, limit := range out.PerTool {
+		limiter.PerTool[name] = tools.OutputLimit{MaxLines: limit.MaxLines, MaxBytes: limit.MaxBytes}
+	}
+	return limiter
+}
+
+// newSearchBackends builds the web search backends from config, primary first.
+func newSearchBackends(cfg *config.Config) []tools.SearchBackend {
+	var backends []tools.SearchBackend
+	for _, name := range cfg.SearchBackends() {
+		switch name {
+		case "brave":
+			backends = append(backends, &tools.BraveBackend{APIKey: cfg.GetBraveAPIKey()})
+		case "tavily":
+			backends = append(backends, &tools.TavilyBackend{APIKey: cfg.GetTavilyAPIKey()})
+		case "searxng":
+			backends = append(backends, &tools.SearXNGBackend{BaseURL: cfg.GetSearXNGURL()})
+		}
+	}
+	return backends
+}
+
+// SetSandboxProfile configures exec sandboxing for the given run mode,
+// "interactive" or "autonomous". New loops use the interactive profile;
+// autonomous runs switch to the autonomous one themselves. It fails if the
+// profile's sandbox doesn't work on this host, rather than letting every
+// command fail later.
+func (l *Loop) SetSandboxProfile(mode string) error {
+	profile := l.cfg.SandboxProfile(mode)
+	if !profile.Enabled {
+		l.exec.SetSandbox(nil)
+		return nil
+	}
+
+	sandbox := &tools.Sandbox{
+		Backend:       profile.Backend,
+		AllowNetwork:  profile.AllowNetwork,
+		WritablePaths: profile.WritablePaths,
+	}
+	if err := sandbox.Check(l.exec.Workspace); err != nil {
+		return fmt.Errorf("exec sandbox for %s mode does not work on this host (%v); "+
+			"install bubblewrap or allow unprivileged user namespaces, "+
+			"or set tools.exec.sandbox.profiles.%s.enabled to false", mode, err, mode)
+	}
+	l.exec.SetSandbox(sandbox)
+	logger.InfoCF("agent", "Exec sandbox enabled", map[string]interface{}{
+		"mode":    mode,
+		"backend": profile.Backend,
+		"network": profile.AllowNetwork,
+	})
+	return nil
 }
 
 // Run starts the agent loop with the given prompt.
@@ -115,10 +299,51 @@ func (l *Loop) Run(ctx context.Context, initialPrompt string) error {
 		}
 	}
 
-	return l.runAgentLoop(ctx, initialPrompt)
+	prompt, err := l.submitPrompt(ctx, initialPrompt)
+	if err != nil {
+		return err
+	}
+	err = l.runAgentLoop(ctx, prompt)
+	status := "done"
+	if err != nil {
+		status = "error"
+	}
+	l.EndSession(status, initialPrompt)
+	return err
+}
+
+// SetOutput redirects the loop's streamed output, e.g. to a task log.
+func (l *Loop) SetOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out = w
+}
+
+// SetCheckpointPath sets where autonomous runs save their checkpoint, e.g.
+// one per queued task.
+func (l *Loop) SetCheckpointPath(path string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.checkpointPath = path
+}
+
+// SetMilestoneHandler sets a function called at autonomous milestones, e.g.
+// to commit the work so far.
+func (l *Loop) SetMilestoneHandler(fn MilestoneFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onMilestone = fn
 }
 
-// Stop stops the agent loop.
+// milestone reports a milestone to the handler, if there is one.
+func (l *Loop) milestone(summary string) {
+	if l.onMilestone != nil {
+		l.onMilestone(summary)
+	}
+}
+
+// Stop stops the agent loop, killing the shell command it is running and
+// ending its sub-agents.
 func (l *Loop) Stop() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -128,6 +353,18 @@ func (l *Loop) Stop() {
 	}
 
 	close(l.stopChan)
+	// Don't wait for a long-running command to finish on its own
+	l.exec.Interrupt()
+	for _, cancel := range l.subAgents {
+		cancel()
+	}
+}
+
+// Close releases resources held by the loop: the persistent shell session
+// and any background processes still running.
+func (l *Loop) Close() {
+	l.exec.Processes.KillAll()
+	l.exec.Close()
 }
 
 // ClearHistory clears the conversation history for interactive mode.
@@ -135,6 +372,10 @@ func (l *Loop) ClearHistory() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.messages = nil
+	l.todos.Set(nil)
+	if l.exec.Shell != nil {
+		l.exec.Shell.Reset()
+	}
 }
 
 // RunContinue continues an interactive conversation.
@@ -155,10 +396,16 @@ func (l *Loop) RunContinue(ctx context.Context, userPrompt string) error {
 		l.mu.Unlock()
 	}()
 
+	userPrompt, err := l.submitPrompt(ctx, userPrompt)
+	if err != nil {
+		fmt.Fprintf(l.out, "[%v]\n", err)
+		return nil
+	}
+
 	// Initialize messages if this is the first call
 	if len(l.messages) == 0 {
 		l.messages = l.buildInitialMessages(userPrompt)
-		l.toolDefs = l.buildToolDefinitions()
+		l.newSession(0)
 	} else {
 		// Append user message to existing history
 		l.messages = append(l.messages, providers.Message{
@@ -167,6 +414,7 @@ func (l *Loop) RunContinue(ctx context.Context, userPrompt string) error {
 		})
 	}
 
+	defer func() { l.saveSession(l.messages) }()
 	return l.runInteractiveLoop(ctx)
 }
 
@@ -185,19 +433,23 @@ func (l *Loop) runInteractiveLoop(ctx context.Context) error {
 		default:
 		}
 
+		// Plan mode can change between turns
+		registry := l.activeTools()
+		toolDefs := toolDefinitions(registry)
+
 		// Call LLM with streaming (with retry for rate limits)
 		var resp *providers.Response
 		var err error
 		for attempt := 0; attempt < 3; attempt++ {
-			resp, err = l.provider.ChatStream(ctx, l.messages, l.toolDefs, l.cfg.Agents.Model, map[string]interface{}{
+			resp, err = l.provider.ChatStream(ctx, l.withToolNotice(l.withTodos(l.messages)), toolDefs, l.cfg.Agents.Model, map[string]interface{}{
 				"max_tokens":  l.cfg.Agents.MaxTokens,
 				"temperature": l.cfg.Agents.Temperature,
 			}, func(event providers.StreamEvent) {
 				switch event.Type {
 				case "text":
-					fmt.Print(event.Text)
+					fmt.Fprint(l.out, event.Text)
 				case "tool_start":
-					fmt.Printf("\n[tool: %s] ", event.Name)
+					fmt.Fprintf(l.out, "\n[tool: %s] ", event.Name)
 				}
 			})
 			if err == nil {
@@ -245,6 +497,13 @@ func (l *Loop) runInteractiveLoop(ctx context.Context) error {
 					Content: resp.Content,
 				})
 			}
+			if feedback, blocked := l.endTurn(ctx, resp.Content); blocked {
+				if resp.Content == "" {
+					l.messages = append(l.messages, providers.Message{Role: "assistant", Content: "(no reply)"})
+				}
+				l.messages = append(l.messages, providers.Message{Role: "user", Content: feedback})
+				continue
+			}
 			return nil
 		}
 
@@ -272,17 +531,8 @@ func (l *Loop) runInteractiveLoop(ctx context.Context) error {
 				"args": utils.Truncate(fmt.Sprintf("%v", tc.Arguments), 100),
 			})
 
-			result, err := l.tools.Execute(ctx, tc.Name, tc.Arguments)
-			if err != nil {
-				result = fmt.Sprintf("Error: %v", err)
-				logger.WarnCF("agent", "Tool execution failed", map[string]interface{}{
-					"tool":  resolvedName,
-					"error": err.Error(),
-				})
-			}
-
-			displayResult := utils.Truncate(result, 200)
-			fmt.Printf("  → %s\n", displayResult)
+			result, _ := l.executeTool(ctx, registry, tc, "agent")
+			l.showToolResult(resolvedName, result)
 
 			l.messages = append(l.messages, providers.Message{
 				Role:       "tool",
@@ -329,9 +579,12 @@ func (l *Loop) runAgentLoop(ctx context.Context, userPrompt string) error {
 
 	// Build initial messages
 	messages := l.buildInitialMessages(userPrompt)
+	l.newSession(0)
+	defer func() { l.saveSession(messages) }()
 
 	// Get tool definitions
-	toolDefs := l.buildToolDefinitions()
+	registry := l.activeTools()
+	toolDefs := toolDefinitions(registry)
 
 	// Main loop
 	var lastToolSig string
@@ -356,15 +609,15 @@ func (l *Loop) runAgentLoop(ctx context.Context, userPrompt string) error {
 		var resp *providers.Response
 		var err error
 		for attempt := 0; attempt < 3; attempt++ {
-			resp, err = l.provider.ChatStream(ctx, messages, toolDefs, l.cfg.Agents.Model, map[string]interface{}{
+			resp, err = l.provider.ChatStream(ctx, l.withToolNotice(l.withTodos(messages)), toolDefs, l.cfg.Agents.Model, map[string]interface{}{
 				"max_tokens":  l.cfg.Agents.MaxTokens,
 				"temperature": l.cfg.Agents.Temperature,
 			}, func(event providers.StreamEvent) {
 				switch event.Type {
 				case "text":
-					fmt.Print(event.Text)
+					fmt.Fprint(l.out, event.Text)
 				case "tool_start":
-					fmt.Printf("\n[tool: %s] ", event.Name)
+					fmt.Fprintf(l.out, "\n[tool: %s] ", event.Name)
 				case "done":
 					// Print newline after streamed text
 				}
@@ -411,14 +664,24 @@ func (l *Loop) runAgentLoop(ctx context.Context, userPrompt string) error {
 		// If no tool calls, we're done
 		if len(resp.ToolCalls) == 0 {
 			if resp.Content != "" {
-				fmt.Println() // newline after streamed text
+				fmt.Fprintln(l.out) // newline after streamed text
+			}
+			if feedback, blocked := l.endTurn(ctx, resp.Content); blocked {
+				content := resp.Content
+				if content == "" {
+					content = "(no reply)"
+				}
+				messages = append(messages,
+					providers.Message{Role: "assistant", Content: content},
+					providers.Message{Role: "user", Content: feedback})
+				continue
 			}
 			return nil
 		}
 
 		// Print newline after streamed content before tool execution output
 		if resp.Content != "" {
-			fmt.Println()
+			fmt.Fprintln(l.out)
 		}
 
 		// Build assistant message with tool calls (use resolved canonical names)
@@ -445,18 +708,8 @@ func (l *Loop) runAgentLoop(ctx context.Context, userPrompt string) error {
 				"args": utils.Truncate(fmt.Sprintf("%v", tc.Arguments), 100),
 			})
 
-			result, err := l.tools.Execute(ctx, tc.Name, tc.Arguments)
-			if err != nil {
-				result = fmt.Sprintf("Error: %v", err)
-				logger.WarnCF("agent", "Tool execution failed", map[string]interface{}{
-					"tool":  tc.Name,
-					"error": err.Error(),
-				})
-			}
-
-			// Truncate long results for display
-			displayResult := utils.Truncate(result, 200)
-			fmt.Printf("  → %s\n", displayResult)
+			result, _ := l.executeTool(ctx, registry, tc, "agent")
+			l.showToolResult(resolvedName, result)
 
 			// Add tool result to messages
 			messages = append(messages, providers.Message{
@@ -506,7 +759,7 @@ func (l *Loop) buildInitialMessages(userPrompt string) []providers.Message {
 	var messages []providers.Message
 
 	// System prompt with memory context
-	systemPrompt := l.buildSystemPrompt()
+	systemPrompt := l.buildSystemPrompt(userPrompt)
 	messages = append(messages, providers.Message{
 		Role:    "system",
 		Content: systemPrompt,
@@ -521,8 +774,9 @@ func (l *Loop) buildInitialMessages(userPrompt string) []providers.Message {
 	return messages
 }
 
-// buildSystemPrompt creates the system prompt with memory context.
-func (l *Loop) buildSystemPrompt() string {
+// buildSystemPrompt creates the system prompt with memory context
+// relevant to the user's prompt.
+func (l *Loop) buildSystemPrompt(userPrompt string) string {
 	// List available tool names
 	toolNames := strings.Join(l.tools.List(), ", ")
 
@@ -531,47 +785,113 @@ func (l *Loop) buildSystemPrompt() string {
 You have the following tools available: %s
 
 Tool usage:
-- Use "exec" to run shell commands (bash/sh). The argument is "command" (string).
-- Use "read_file" to read file contents. The argument is "path" (string).
+- Use "exec" to run shell commands (bash/sh). The argument is "command" (string). %s
+  Set "background": true for long-running commands (dev servers, watchers); manage them with "process_output", "process_input", "process_list" and "process_kill".
+- Use "read_file" to read file contents. The argument is "path" (string); optional "offset" and "limit" select a line range.
 - Use "write_file" to create/overwrite files. Arguments: "path" and "content".
 - Use "edit_file" to make targeted edits. Arguments: "path", "old_string", "new_string".
 - Use "list_dir" to list directory contents. The argument is "path" (string).
 - Use "glob" to find files by pattern. The argument is "pattern" (string).
-- Use "grep" to search file contents. Arguments: "pattern" and optionally "path", "include".
+- Use "grep" to search file contents. Arguments: "pattern" and optionally "path", "include", "type", "output_mode" (content, files_with_matches, count), "case_insensitive", "multiline", "context", "head_limit", "offset".
 - Use "web_search" to search the web. The argument is "query" (string).
+- Use "web_fetch" to read a web page as markdown. The argument is "url" (string).
+- Use "remember" to save a durable fact to long-term memory. Arguments: "content" and optionally "section".
+- Use "note" to add progress to today's daily log, "recall" to search memory ("query") and "forget" to remove an outdated entry ("entry").
+- The memory tools take an optional "scope": "project" for the current repository's memory, "global" (default) for memory shared across projects.
+- Use "memory_search" to find relevant past notes, memory sections and earlier sessions, ranked by relevance. The argument is "query".
+- Use "todo" to track multi-step work. The argument is "todos": the full list of {"content", "status"} items (pending, in_progress, done); each call replaces the list.
+- Use "delegate" to hand broad investigations to sub-agents with a fresh context; only their summaries come back. Arguments: "tasks" (one self-contained prompt per sub-agent; several run concurrently) and optionally "access" ("read_only" or "full").
 
 IMPORTANT: Only use the tool names listed above. Do NOT use tool names like "Bash", "Read", "Write", etc.
 
 Be concise and helpful. Focus on completing the task efficiently.
-`, toolNames)
+`, toolNames, l.shellNote())
 
 	// Add memory context
-	memoryCtx := l.memory.GetMemoryContext(l.cfg.Memory.DailyNotesDays)
-	if memoryCtx != "" {
+	if memoryCtx := l.memoryContext(userPrompt); memoryCtx != "" {
 		basePrompt += "\n---\n\n" + memoryCtx
 	}
 
 	return basePrompt
 }
 
+// shellNote tells the model whether shell state carries over between exec
+// calls, which depends on the persistent shell being available.
+func (l *Loop) shellNote() string {
+	if l.exec.Shell != nil {
+		return "The shell session persists between calls: cd and exported variables carry over."
+	}
+	return "Each call starts in a fresh shell in the working directory: cd and exported variables do not carry over."
+}
+
+// memoryContext returns the memory to inject into a system prompt. In
+// "relevant" mode only the global snippets that best match query are
+// included; project memory is always included in full.
+func (l *Loop) memoryContext(query string) string {
+	if l.cfg.Memory.ContextMode == "full" || strings.TrimSpace(query) == "" {
+		return l.fullMemoryContext()
+	}
+	memoryCtx := l.memory.GetRelevantContext(query, l.cfg.SessionsDir(), l.cfg.Memory.ContextResults)
+	if memoryCtx != "" {
+		memoryCtx += "\nUse memory_search for more.\n"
+	}
+	return l.withProjectMemory(memoryCtx)
+}
+
+// fullMemoryContext returns all long-term memory and recent daily notes.
+func (l *Loop) fullMemoryContext() string {
+	return l.withProjectMemory(l.memory.GetMemoryContext(l.cfg.Memory.DailyNotesDays))
+}
+
+// withProjectMemory layers the current repository's memory over the global
+// memory context.
+func (l *Loop) withProjectMemory(globalCtx string) string {
+	if l.project == nil {
+		return globalCtx
+	}
+	projectCtx := l.project.GetMemoryContext(l.cfg.Memory.DailyNotesDays)
+	return memory.MergeContexts(l.project, projectCtx, globalCtx)
+}
+
 // buildToolDefinitions creates tool definitions for the LLM.
 func (l *Loop) buildToolDefinitions() []providers.ToolDefinition {
-	defs := l.tools.GetDefinitions()
-	var result []providers.ToolDefinition
-
-	for _, def := range defs {
-		fn := def["function"].(map[string]interface{})
-		result = append(result, providers.ToolDefinition{
-			Type: "function",
-			Function: providers.ToolFunctionDefinition{
-				Name:        fn["name"].(string),
-				Description: fn["description"].(string),
-				Parameters:  fn["parameters"].(map[string]interface{}),
-			},
-		})
+	return toolDefinitions(l.tools)
+}
+
+// showToolResult prints a tool result for the user: the todo list in
+// full, anything else truncated.
+func (l *Loop) showToolResult(name, result string) {
+	if name == "todo" && !strings.HasPrefix(result, "Error:") {
+		fmt.Fprintf(l.out, "\n%s", l.todos.Render())
+		return
+	}
+	fmt.Fprintf(l.out, "  → %s\n", utils.Truncate(result, 200))
+}
+
+// doneTodos returns the contents of the items that are done.
+func doneTodos(items []tools.TodoItem) map[string]bool {
+	done := make(map[string]bool)
+	for _, item := range items {
+		if item.Status == tools.TodoDone {
+			done[item.Content] = true
+		}
 	}
+	return done
+}
 
-	return result
+// withTodos returns messages with the current todo list appended to the
+// system prompt, so the plan stays in view however long the history grows.
+func (l *Loop) withTodos(messages []providers.Message) []providers.Message {
+	if len(messages) == 0 || messages[0].Role != "system" {
+		return messages
+	}
+	list := l.todos.Render()
+	if list == "" {
+		return messages
+	}
+	out := append([]providers.Message(nil), messages...)
+	out[0].Content += fmt.Sprintf("\n---\n\n## Current Todo List (%s)\n\n%sKeep it up to date with the todo tool.\n", l.todos.Progress(), list)
+	return out
 }
 
 // checkStrategicBoundary checks for strategic compact boundary patterns.
@@ -635,7 +955,7 @@ Run 'domiclaw resume' to continue.
 
 // generateGapAnalysisPrompt creates the prompt for gap analysis recovery.
 func (l *Loop) generateGapAnalysisPrompt() string {
-	memoryCtx := l.memory.GetMemoryContext(l.cfg.Memory.DailyNotesDays)
+	memoryCtx := l.fullMemoryContext()
 
 	return fmt.Sprintf(`# Session Recovery - Gap Analysis
 
@@ -661,6 +981,26 @@ Please perform gap analysis and then continue the task.
 `, memoryCtx)
 }
 
+// Ask sends a single prompt to the model without tools or history and
+// returns its reply. It is used for housekeeping such as memory consolidation.
+func (l *Loop) Ask(ctx context.Context, prompt string) (string, error) {
+	resp, err := l.provider.Chat(ctx, []providers.Message{
+		{Role: "user", Content: prompt},
+	}, nil, l.cfg.Agents.Model, map[string]interface{}{
+		"max_tokens":  l.cfg.Agents.MaxTokens,
+		"temperature": 0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("LLM call failed: %w", err)
+	}
+	return resp.Content, nil
+}
+
+// Todos returns the agent's todo list for the current session.
+func (l *Loop) Todos() *tools.TodoList {
+	return l.todos
+}
+
 // GetTools returns the tool registry for external access.
 func (l *Loop) GetTools() *tools.Registry {
 	return l.tools
@@ -668,11 +1008,30 @@ func (l *Loop) GetTools() *tools.Registry {
 
 // RunAutonomous runs the agent in fully autonomous mode.
 // The agent will plan, execute, iterate, and self-evaluate until the task is complete.
-func (l *Loop) RunAutonomous(ctx context.Context, taskDescription string) error {
+// The result is returned even when err is non-nil.
+func (l *Loop) RunAutonomous(ctx context.Context, taskDescription string) (*AutonomousResult, error) {
+	cp := &Checkpoint{
+		Task:       taskDescription,
+		WorkingDir: l.exec.Workspace,
+		Started:    time.Now(),
+	}
+	return l.runAutonomous(ctx, cp, false)
+}
+
+// ResumeAutonomous continues an autonomous run from a checkpoint, with its
+// message history, plan, milestones and usage restored. A history that no
+// longer fits the context window is compacted first.
+func (l *Loop) ResumeAutonomous(ctx context.Context, cp *Checkpoint) (*AutonomousResult, error) {
+	return l.runAutonomous(ctx, cp, true)
+}
+
+func (l *Loop) runAutonomous(ctx context.Context, cp *Checkpoint, resume bool) (result *AutonomousResult, err error) {
+	result = &AutonomousResult{Status: "stopped"}
+
 	l.mu.Lock()
 	if l.running {
 		l.mu.Unlock()
-		return fmt.Errorf("agent is already running")
+		return result, fmt.Errorf("agent is already running")
 	}
 	l.running = true
 	l.stopChan = make(chan struct{})
@@ -683,21 +1042,77 @@ func (l *Loop) RunAutonomous(ctx context.Context, taskDescription string) error
 		l.running = false
 		l.mu.Unlock()
 	}()
+	defer func() {
+		status := result.Status
+		if err != nil && !errors.Is(err, context.Canceled) {
+			status = "failed"
+		}
+		l.EndSession(status, cp.Task)
+	}()
+
+	// Autonomous runs get the (stricter) autonomous sandbox profile
+	if err := l.SetSandboxProfile("autonomous"); err != nil {
+		return result, err
+	}
+
+	if resume {
+		if l.needsCompaction(cp) {
+			before := len(cp.Messages)
+			l.compactCheckpoint(ctx, cp)
+			logger.InfoCF("auto", "Compacted checkpoint history", map[string]interface{}{
+				"messages_before": before,
+				"messages_after":  len(cp.Messages),
+			})
+		}
+		l.messages = cp.Messages
+		if len(l.messages) == 0 {
+			// A damaged or hand-edited checkpoint lost its history: start
+			// over from the task, keeping the cycle count and usage
+			l.messages = []providers.Message{
+				{Role: "system", Content: l.buildAutonomousSystemPrompt(cp.Task)},
+				{Role: "user", Content: "Execute this task autonomously:\n\n" + cp.Task},
+			}
+		}
+		l.todos.Set(cp.Todos)
+		reason := cp.Reason
+		if reason == "" {
+			reason = cp.Status
+		}
+		notice := fmt.Sprintf("This task was interrupted (%s) after %d cycles and has now been resumed. "+
+			"Verify the current state of any files you were working on, then continue with the next step.", reason, cp.Cycles)
+		if last := &l.messages[len(l.messages)-1]; last.Role == "user" {
+			last.Content += "\n\n" + notice
+		} else {
+			l.messages = append(l.messages, providers.Message{Role: "user", Content: notice})
+		}
 
-	// Build autonomous system prompt
-	autonomousPrompt := l.buildAutonomousSystemPrompt(taskDescription)
+		l.memory.AppendToday(fmt.Sprintf(`## Autonomous Task Resumed
 
-	// Initialize messages
-	l.messages = []providers.Message{
-		{Role: "system", Content: autonomousPrompt},
-		{Role: "user", Content: fmt.Sprintf(`Execute this task autonomously:
+Time: %s
+Task: %s
+Cycles so far: %d
+`, time.Now().Format("15:04:05"), cp.Task, cp.Cycles))
+	} else {
+		l.todos.Set(nil)
+		task, err := l.submitPrompt(ctx, cp.Task)
+		if err != nil {
+			return result, err
+		}
+
+		// Build autonomous system prompt
+		autonomousPrompt := l.buildAutonomousSystemPrompt(cp.Task)
+
+		// Initialize messages
+		l.messages = []providers.Message{
+			{Role: "system", Content: autonomousPrompt},
+			{Role: "user", Content: fmt.Sprintf(`Execute this task autonomously:
 
 %s
 
 Work through this step by step:
 1. First, analyze the current state (list files, read relevant code)
-2. Create a plan with specific milestones
-3. Execute each step, verifying results
+2. Create a plan with specific milestones, written down with the todo tool
+3. Execute each step, verifying results and updating the todo list
 4. If you encounter errors, debug and fix them
 5. Continue until the task is fully complete
 
@@ -705,42 +1120,99 @@ When you have completed the entire task, end your response with: [TASK_COMPLETE]
 
 If you need to pause or cannot continue, end with: [TASK_PAUSED]
 
-Begin now.`, taskDescription)},
-	}
-	l.toolDefs = l.buildToolDefinitions()
+Begin now.`, task)},
+		}
 
-	// Log to daily notes
-	l.memory.AppendToday(fmt.Sprintf(`## Autonomous Task Started
+		// Log to daily notes
+		l.memory.AppendToday(fmt.Sprintf(`## Autonomous Task Started
 
 Time: %s
 Task: %s
-`, time.Now().Format("15:04:05"), taskDescription))
+`, time.Now().Format("15:04:05"), cp.Task))
+	}
+	l.toolDefs = l.buildToolDefinitions()
+
+	// A resumed history is in the transcript of the run that made it
+	saved := 0
+	if resume {
+		saved = len(l.messages)
+	}
+	l.newSession(saved)
+
+	// checkpoint saves the run's state; it is called after every cycle
+	checkpoint := func(status, reason string) {
+		cp.Status = status
+		cp.Reason = reason
+		cp.Messages = l.messages
+		cp.Todos = l.todos.Items()
+		if err := cp.save(l.checkpointPath); err != nil {
+			logger.WarnCF("auto", "Failed to save checkpoint", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		l.saveSession(l.messages)
+	}
+
+	// Budgets apply to this run; usage over all resumes is in the checkpoint
+	maxCycles := l.cfg.Agents.MaxCycles
+	if maxCycles <= 0 {
+		maxCycles = 100
+	}
+	startUsage := cp.Usage
 
-	// Run autonomous loop - continues until task complete or error
-	maxCycles := 100 // Safety limit
 	for cycle := 0; cycle < maxCycles; cycle++ {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return result, ctx.Err()
 		case <-l.stopChan:
-			return nil
+			checkpoint("stopped", "stopped by user")
+			return result, nil
 		default:
 		}
 
-		fmt.Printf("\n--- Cycle %d ---\n", cycle+1)
+		cp.Cycles++
+		fmt.Fprintf(l.out, "\n--- Cycle %d ---\n", cp.Cycles)
 
 		// Run one cycle
-		completed, err := l.runAutonomousCycle(ctx)
+		scanFrom := len(l.messages)
+		completed, err := l.runAutonomousCycle(ctx, cp)
+		var reached []Milestone
+		for _, msg := range l.messages[scanFrom:] {
+			if msg.Role == "assistant" && msg.Content != "" {
+				reached = append(reached, cp.track(msg.Content, l.cfg.StrategicCompact.BoundaryPatterns)...)
+			}
+		}
+		if len(reached) > 0 {
+			l.milestone(reached[len(reached)-1].Text)
+		}
+		result.Cycles = cycle + 1
+		result.Message = l.lastAssistantMessage()
+		result.Verification = cp.Verification
+		if errors.Is(err, errTaskPaused) {
+			result.Status = "paused"
+			result.Reason = "paused by agent"
+			checkpoint("paused", result.Reason)
+			l.memory.AppendToday(fmt.Sprintf(`## Autonomous Task Paused
+
+Time: %s
+Cycles: %d
+`, time.Now().Format("15:04:05"), cp.Cycles))
+			return result, nil
+		}
 		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				checkpoint("stopped", "interrupted")
+				return result, err
+			}
 			if l.detectContextOverflow(err) {
-				// Save state for resume
-				l.memory.WriteResumeTrigger(fmt.Sprintf("auto_%d", time.Now().Unix()), "context_overflow")
-				l.memory.WriteResumePrompt(fmt.Sprintf("Continue autonomous task: %s", taskDescription))
-				return fmt.Errorf("context overflow - run 'domiclaw resume' to continue")
+				// The checkpoint is compacted when the run is resumed
+				checkpoint("context_overflow", "context overflow")
+				l.memory.WriteResumeTrigger(fmt.Sprintf("auto_%d", time.Now().Unix()), "autonomous_context_overflow")
+				return result, fmt.Errorf("context overflow - run 'domiclaw auto --resume' to continue")
 			}
 			// Log error but try to continue
 			logger.WarnCF("auto", "Cycle error, continuing", map[string]interface{}{
-				"cycle": cycle,
+				"cycle": cp.Cycles,
 				"error": err.Error(),
 			})
 			// Add error to context so agent can learn from it
@@ -748,6 +1220,7 @@ Task: %s
 				Role:    "user",
 				Content: fmt.Sprintf("An error occurred: %s\n\nPlease analyze this error and continue with the task.", err.Error()),
 			})
+			checkpoint("running", "")
 			continue
 		}
 
@@ -756,29 +1229,59 @@ Task: %s
 
 Time: %s
 Cycles: %d
-`, time.Now().Format("15:04:05"), cycle+1))
-			return nil
+%s`, time.Now().Format("15:04:05"), cp.Cycles, FormatVerification(cp.Verification)))
+			result.Status = "complete"
+			// Nothing left to resume
+			if err := os.Remove(l.checkpointPath); err != nil && !os.IsNotExist(err) {
+				logger.WarnCF("auto", "Failed to remove checkpoint", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			return result, nil
 		}
 
-		// Add continuation prompt if agent stopped without completing
-		lastMsg := ""
+		// Add continuation prompt if agent stopped without completing,
+		// unless it was already given feedback
+		lastMsg := providers.Message{}
 		if len(l.messages) > 0 {
-			lastMsg = l.messages[len(l.messages)-1].Content
+			lastMsg = l.messages[len(l.messages)-1]
 		}
-		if !strings.Contains(lastMsg, "TASK_COMPLETE") && !strings.Contains(lastMsg, "TASK_PAUSED") {
+		if lastMsg.Role != "user" && !strings.Contains(lastMsg.Content, "TASK_COMPLETE") && !strings.Contains(lastMsg.Content, "TASK_PAUSED") {
 			l.messages = append(l.messages, providers.Message{
 				Role:    "user",
 				Content: "Continue with the task. What's the next step?",
 			})
 		}
+
+		if budget := l.cfg.Agents.MaxRunTokens; budget > 0 && cp.Usage.Total()-startUsage.Total() >= budget {
+			result.Status = "paused"
+			result.Reason = fmt.Sprintf("token budget (%d) reached", budget)
+			checkpoint("paused", result.Reason)
+			return result, nil
+		}
+		checkpoint("running", "")
 	}
 
-	return fmt.Errorf("max cycles (%d) reached", maxCycles)
+	result.Status = "paused"
+	result.Reason = fmt.Sprintf("cycle limit (%d) reached", maxCycles)
+	checkpoint("paused", result.Reason)
+	return result, nil
+}
+
+// lastAssistantMessage returns the content of the latest assistant message
+// that has any text.
+func (l *Loop) lastAssistantMessage() string {
+	for i := len(l.messages) - 1; i >= 0; i-- {
+		if msg := l.messages[i]; msg.Role == "assistant" && strings.TrimSpace(msg.Content) != "" {
+			return msg.Content
+		}
+	}
+	return ""
 }
 
 // runAutonomousCycle runs a single cycle of autonomous execution.
 // Returns true if the task is complete.
-func (l *Loop) runAutonomousCycle(ctx context.Context) (bool, error) {
+func (l *Loop) runAutonomousCycle(ctx context.Context, cp *Checkpoint) (bool, error) {
 	var lastToolSig string
 	repeatCount := 0
 	const maxRepeats = 2
@@ -796,15 +1299,15 @@ func (l *Loop) runAutonomousCycle(ctx context.Context) (bool, error) {
 		var resp *providers.Response
 		var err error
 		for attempt := 0; attempt < 3; attempt++ {
-			resp, err = l.provider.ChatStream(ctx, l.messages, l.toolDefs, l.cfg.Agents.Model, map[string]interface{}{
+			resp, err = l.provider.ChatStream(ctx, l.withTodos(l.messages), l.toolDefs, l.cfg.Agents.Model, map[string]interface{}{
 				"max_tokens":  l.cfg.Agents.MaxTokens,
 				"temperature": l.cfg.Agents.Temperature,
 			}, func(event providers.StreamEvent) {
 				switch event.Type {
 				case "text":
-					fmt.Print(event.Text)
+					fmt.Fprint(l.out, event.Text)
 				case "tool_start":
-					fmt.Printf("\n[tool: %s] ", event.Name)
+					fmt.Fprintf(l.out, "\n[tool: %s] ", event.Name)
 				}
 			})
 			if err == nil {
@@ -833,6 +1336,8 @@ func (l *Loop) runAutonomousCycle(ctx context.Context) (bool, error) {
 			return false, err
 		}
 
+		cp.Usage.Input += resp.Usage.PromptTokens
+		cp.Usage.Output += resp.Usage.CompletionTokens
 		logger.InfoCF("auto", "LLM response", map[string]interface{}{
 			"tokens_in":  resp.Usage.PromptTokens,
 			"tokens_out": resp.Usage.CompletionTokens,
@@ -845,6 +1350,34 @@ func (l *Loop) runAutonomousCycle(ctx context.Context) (bool, error) {
 				Role:    "assistant",
 				Content: resp.Content,
 			})
+			// Not done while the plan still has open items
+			if open := l.todos.Open(); len(open) > 0 {
+				fmt.Fprintf(l.out, "\n[Completion rejected: %d open todo items]\n", len(open))
+				l.messages = append(l.messages, providers.Message{
+					Role: "user",
+					Content: fmt.Sprintf("You signaled TASK_COMPLETE, but %d items on your todo list are still open:\n\n%s\n"+
+						"Finish them first. If some are already done or no longer needed, update the todo list, then signal completion again.",
+						len(open), tools.RenderTodos(open)),
+				})
+				return false, nil
+			}
+
+			// Nor while a turn_end hook objects
+			if feedback, blocked := l.endTurn(ctx, resp.Content); blocked {
+				l.messages = append(l.messages, providers.Message{Role: "user", Content: feedback})
+				return false, nil
+			}
+
+			// Nor while the build or tests fail
+			results := l.verify(ctx)
+			cp.Verification = results
+			if verificationFailed(results) {
+				l.messages = append(l.messages, providers.Message{
+					Role:    "user",
+					Content: verifyFeedback(results),
+				})
+				return false, nil
+			}
 			return true, nil
 		}
 
@@ -853,7 +1386,11 @@ func (l *Loop) runAutonomousCycle(ctx context.Context) (bool, error) {
 				Role:    "assistant",
 				Content: resp.Content,
 			})
-			return false, fmt.Errorf("task paused by agent")
+			if feedback, blocked := l.endTurn(ctx, resp.Content); blocked {
+				l.messages = append(l.messages, providers.Message{Role: "user", Content: feedback})
+				return false, nil
+			}
+			return false, errTaskPaused
 		}
 
 		// No tool calls = end of cycle (agent is thinking/responding)
@@ -862,6 +1399,9 @@ func (l *Loop) runAutonomousCycle(ctx context.Context) (bool, error) {
 				Role:    "assistant",
 				Content: resp.Content,
 			})
+			if feedback, blocked := l.endTurn(ctx, resp.Content); blocked {
+				l.messages = append(l.messages, providers.Message{Role: "user", Content: feedback})
+			}
 			return false, nil // Not complete, but cycle done
 		}
 
@@ -889,17 +1429,20 @@ func (l *Loop) runAutonomousCycle(ctx context.Context) (bool, error) {
 				"args": utils.Truncate(fmt.Sprintf("%v", tc.Arguments), 100),
 			})
 
-			result, err := l.tools.Execute(ctx, tc.Name, tc.Arguments)
-			if err != nil {
-				result = fmt.Sprintf("Error: %v", err)
-				logger.WarnCF("auto", "Tool error", map[string]interface{}{
-					"tool":  resolvedName,
-					"error": err.Error(),
-				})
+			wasDone := doneTodos(l.todos.Items())
+			result, err := l.executeTool(ctx, l.tools, tc, "auto")
+			if resolvedName == "todo" && err == nil {
+				var finished []string
+				for _, item := range l.todos.Items() {
+					if item.Status == tools.TodoDone && !wasDone[item.Content] {
+						finished = append(finished, item.Content)
+					}
+				}
+				if len(finished) > 0 {
+					l.milestone(strings.Join(finished, "; "))
+				}
 			}
-
-			displayResult := utils.Truncate(result, 200)
-			fmt.Printf("  → %s\n", displayResult)
+			l.showToolResult(resolvedName, result)
 
 			l.messages = append(l.messages, providers.Message{
 				Role:       "tool",
@@ -941,14 +1484,20 @@ func (l *Loop) buildAutonomousSystemPrompt(taskDescription string) string {
 You have these tools: %s
 
 Tool usage:
-- "exec" - run shell commands (the argument is "command")
-- "read_file" - read file contents (argument: "path")
+- "exec" - run shell commands (the argument is "command"; set "background": true for servers/watchers)
+- "process_output", "process_input", "process_list", "process_kill" - manage background processes (argument: "id")
+- "read_file" - read file contents (argument: "path"; optional "offset", "limit")
 - "write_file" - create/overwrite files (arguments: "path", "content")
 - "edit_file" - targeted edits (arguments: "path", "old_string", "new_string")
 - "list_dir" - list directory (argument: "path")
 - "glob" - find files by pattern (argument: "pattern")
-- "grep" - search file contents (arguments: "pattern", optionally "path", "include")
+- "grep" - search file contents (arguments: "pattern", optionally "path", "include", "type", "output_mode", "context", "head_limit", "offset")
 - "web_search" - search the web (argument: "query")
+- "web_fetch" - fetch a URL as markdown (argument: "url")
+- "remember", "note", "recall", "forget" - long-term memory, daily log, memory search and removal (optional "scope": "project" or "global")
+- "memory_search" - ranked search over memory, daily notes and past sessions (argument: "query")
+- "todo" - write your plan as a todo list and update it as you go (argument: "todos", the full list of {"content", "status"}; status is pending, in_progress or done)
+- "delegate" - run sub-agents with a fresh context on self-contained investigations and get back only their summaries (arguments: "tasks", one prompt per sub-agent, run concurrently; optional "access": "read_only" or "full")
 
 AUTONOMOUS MODE GUIDELINES:
 1. **Plan First**: Before coding, understand the current state and create a clear plan
@@ -956,7 +1505,7 @@ AUTONOMOUS MODE GUIDELINES:
 3. **Verify Results**: After each action, verify it worked as expected
 4. **Handle Errors**: If something fails, debug and fix it before continuing
 5. **Stay Focused**: Keep working on the task until it's fully complete
-6. **Signal Completion**: When done, include "[TASK_COMPLETE]" in your response
+6. **Signal Completion**: When done, include "[TASK_COMPLETE]" in your response. Completion is rejected while todo items are open
 7. **Signal Pause**: If you truly cannot continue, include "[TASK_PAUSED]" with explanation
 
 You can run shell commands, create/edit files, search code, and browse the web. Use all tools available to complete the task.
@@ -964,9 +1513,16 @@ You can run shell commands, create/edit files, search code, and browse the web.
 IMPORTANT: Only use the exact tool names listed above. Do NOT use "Bash", "Read", "Write", etc.
 `, toolNames)
 
+	if sandbox := l.exec.CurrentSandbox(); sandbox != nil {
+		basePrompt += fmt.Sprintf("\nShell commands run in a sandbox (%s). Only the working directory is writable.\n", sandbox.Describe())
+	}
+	if commands := l.verifyCommands(); l.cfg.Verify.Enabled && len(commands) > 0 {
+		basePrompt += fmt.Sprintf("\nWhen you signal [TASK_COMPLETE], these checks run automatically and must pass: `%s`. Run them yourself before claiming completion.\n",
+			strings.Join(commands, "`, `"))
+	}
+
 	// Add memory context
-	memoryCtx := l.memory.GetMemoryContext(l.cfg.Memory.DailyNotesDays)
-	if memoryCtx != "" {
+	if memoryCtx := l.memoryContext(taskDescription); memoryCtx != "" {
 		basePrompt += "\n---\n\n" + memoryCtx
 	}
 
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DomiYoung/domiclaw/pkg/providers"
+	"github.com/DomiYoung/domiclaw/pkg/tools"
+)
+
+// PlanApprovedPrompt tells the agent to carry out the plan it proposed.
+const PlanApprovedPrompt = `The user approved your plan. You now have the full tool set: execute the plan step by step, tracking the steps with the todo tool, and verify the result. If something turns out differently than planned, adapt and say so.`
+
+// planModeInstructions is added to the system prompt in plan mode.
+const planModeInstructions = `## PLAN MODE
+
+You are in plan mode. You may only use these read-only tools: %s
+Do not modify files, run commands or try any other tool; they are not available until the user approves your plan.
+
+Study the code and whatever else the request needs, then reply with a plan in this structure:
+
+### Goal
+What will be achieved, in a sentence or two.
+
+### Findings
+The relevant facts you found, with file paths.
+
+### Changes
+Each file to create or modify and what changes in it.
+
+### Steps
+A numbered list of steps in the order you will carry them out.
+
+### Verification
+How you will check that it works (commands to run, behavior to test).
+
+### Risks
+Open questions and anything that could go wrong.
+
+Ask a question instead if the request is too unclear to plan.
+`
+
+// SetPlanMode turns plan mode on or off for the following turns. In plan
+// mode only ReadOnlyTools are offered and the agent is asked for a plan.
+func (l *Loop) SetPlanMode(on bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.planMode = on
+}
+
+// PlanMode reports whether the loop is in plan mode.
+func (l *Loop) PlanMode() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.planMode
+}
+
+// SetAllowedTools restricts the tools offered in the following turns to
+// names, e.g. for a custom slash command. nil offers all tools again.
+func (l *Loop) SetAllowedTools(names []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.allowed = names
+}
+
+// activeTools returns the tools on offer: those allowed, if restricted,
+// and of those only the read-only ones in plan mode.
+func (l *Loop) activeTools() *tools.Registry {
+	l.mu.Lock()
+	planMode, allowed := l.planMode, l.allowed
+	l.mu.Unlock()
+
+	registry := l.tools
+	if allowed != nil {
+		registry = registry.Subset(allowed...)
+	}
+	if planMode {
+		registry = registry.Subset(ReadOnlyTools...)
+	}
+	return registry
+}
+
+// withToolNotice returns messages with a note on the restricted tools
+// appended to a copy of the system message: the plan mode instructions, or
+// the tools allowed for this request.
+func (l *Loop) withToolNotice(messages []providers.Message) []providers.Message {
+	l.mu.Lock()
+	planMode, restricted := l.planMode, l.allowed != nil
+	l.mu.Unlock()
+	if (!planMode && !restricted) || len(messages) == 0 || messages[0].Role != "system" {
+		return messages
+	}
+
+	toolNames := "none"
+	if names := l.activeTools().List(); len(names) > 0 {
+		toolNames = strings.Join(names, ", ")
+	}
+	notice := fmt.Sprintf("## Restricted Tools\n\nFor this request you may only use these tools: %s\nDo not try any other tool.\n", toolNames)
+	if planMode {
+		notice = fmt.Sprintf(planModeInstructions, toolNames)
+	}
+
+	out := append([]providers.Message(nil), messages...)
+	out[0].Content += "\n---\n\n" + notice
+	return out
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/logger"
+	"github.com/DomiYoung/domiclaw/pkg/providers"
+	"github.com/DomiYoung/domiclaw/pkg/session"
+)
+
+// newSession starts a new session transcript for a history whose first
+// saved messages are already recorded elsewhere, e.g. by the run a
+// checkpoint was resumed from.
+func (l *Loop) newSession(saved int) {
+	l.sessionID = fmt.Sprintf("%s-%d", time.Now().Format("20060102-150405"), os.Getpid())
+	l.sessionSaved = saved
+}
+
+// saveSession adds the user and assistant messages of history that are not
+// in the session transcript yet, records the current todo list, and writes
+// the session to the sessions directory, where memory_search finds it in
+// later sessions. Tool calls and results are left out.
+func (l *Loop) saveSession(history []providers.Message) {
+	if l.sessionID == "" {
+		l.newSession(0)
+	}
+	if l.sessionSaved > len(history) {
+		l.sessionSaved = len(history)
+	}
+	changed := false
+	for _, msg := range history[l.sessionSaved:] {
+		if (msg.Role == "user" || msg.Role == "assistant") && strings.TrimSpace(msg.Content) != "" {
+			l.sessions.AddMessage(l.sessionID, msg.Role, msg.Content)
+			changed = true
+		}
+	}
+	l.sessionSaved = len(history)
+
+	var todos []session.Todo
+	for _, item := range l.todos.Items() {
+		todos = append(todos, session.Todo{Content: item.Content, Status: item.Status})
+	}
+	sess := l.sessions.GetOrCreate(l.sessionID)
+	if !reflect.DeepEqual(todos, l.sessions.GetTodos(l.sessionID)) {
+		l.sessions.SetTodos(l.sessionID, todos)
+		changed = true
+	}
+	if !changed {
+		return
+	}
+
+	if err := l.sessions.Save(sess); err != nil {
+		logger.WarnCF("agent", "Failed to save session", map[string]interface{}{
+			"session": l.sessionID,
+			"error":   err.Error(),
+		})
+	}
+}
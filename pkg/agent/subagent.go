@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/DomiYoung/domiclaw/pkg/tools"
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// subAgentExcluded are tools a sub-agent never gets: delegating again would
+// nest without bound, and the todo list is the caller's plan.
+var subAgentExcluded = map[string]bool{
+	"delegate": true,
+	"todo":     true,
+}
+
+// subAgentTools returns the tools for a sub-agent with the given access.
+func (l *Loop) subAgentTools(access string) []string {
+	if access != tools.AccessFull {
+		return ReadOnlyTools
+	}
+	var names []string
+	for _, name := range l.tools.List() {
+		if !subAgentExcluded[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// spawnSubAgent runs prompt as a sub-agent: a turn with its own history and
+// a restricted tool set, whose final reply is the summary for the caller.
+func (l *Loop) spawnSubAgent(ctx context.Context, prompt, access string) (string, error) {
+	ctx, done, err := l.startSubAgent(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	names := l.subAgentTools(access)
+	registry := l.tools.Subset(names...)
+	if access == tools.AccessFull {
+		// Sub-agents run in parallel: each gets its own shell and background
+		// processes so they don't change each other's cwd or kill each
+		// other's servers
+		execTool := newExecTool(l.cfg, l.exec.Workspace)
+		execTool.SetSandbox(l.exec.CurrentSandbox())
+		defer func() {
+			execTool.Processes.KillAll()
+			execTool.Close()
+		}()
+		registerExecTools(registry, execTool)
+	}
+	label := utils.Truncate(strings.Join(strings.Fields(prompt), " "), 60)
+	fmt.Fprintf(l.out, "\n[sub-agent] %s\n", label)
+
+	reply, err := l.RunTurn(ctx, prompt, TurnOptions{
+		Tools:        names,
+		Registry:     registry,
+		SystemPrompt: l.buildSubAgentPrompt(registry.List(), prompt),
+	})
+	if err != nil {
+		fmt.Fprintf(l.out, "[sub-agent failed] %s: %v\n", label, err)
+		return "", err
+	}
+	fmt.Fprintf(l.out, "[sub-agent done] %s\n", label)
+	return reply, nil
+}
+
+// startSubAgent returns a context for a sub-agent that Stop cancels, which
+// ends its turn and kills the command it is running, and a function to call
+// when the sub-agent is done.
+func (l *Loop) startSubAgent(ctx context.Context) (context.Context, func(), error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-l.stopChan:
+		cancel()
+		return nil, nil, fmt.Errorf("agent stopped")
+	default:
+	}
+	if l.subAgents == nil {
+		l.subAgents = make(map[int]context.CancelFunc)
+	}
+	id := l.nextSubAgent
+	l.nextSubAgent++
+	l.subAgents[id] = cancel
+
+	return ctx, func() {
+		l.mu.Lock()
+		delete(l.subAgents, id)
+		l.mu.Unlock()
+		cancel()
+	}, nil
+}
+
+// buildSubAgentPrompt creates the system prompt for a sub-agent.
+func (l *Loop) buildSubAgentPrompt(toolNames []string, prompt string) string {
+	basePrompt := fmt.Sprintf(`You are a DomiClaw sub-agent. Another agent delegated the task below to you; it cannot see your work, only your final reply.
+
+Working directory: %s
+You have only these tools: %s
+
+Investigate as much as the task needs, then reply with a concise summary of your findings: the facts, file paths with line numbers and any open questions. Do not paste whole files. Your reply is all the other agent gets, so make it complete.
+`, l.exec.Workspace, strings.Join(toolNames, ", "))
+
+	if memoryCtx := l.memoryContext(prompt); memoryCtx != "" {
+		basePrompt += "\n---\n\n" + memoryCtx
+	}
+	return basePrompt
+}
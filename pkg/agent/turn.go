@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/DomiYoung/domiclaw/pkg/logger"
+	"github.com/DomiYoung/domiclaw/pkg/providers"
+	"github.com/DomiYoung/domiclaw/pkg/tools"
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// ReadOnlyTools inspect the working directory, the web and memory without
+// changing anything.
+var ReadOnlyTools = []string{
+	"read_file", "list_dir", "glob", "grep",
+	"web_search", "web_fetch",
+	"recall", "memory_search",
+}
+
+// TurnOptions configures RunTurn.
+type TurnOptions struct {
+	Tools         []string        // Tools offered to the model; none if empty
+	Registry      *tools.Registry // Where Tools are looked up; defaults to the loop's tools
+	SystemPrompt  string          // Replaces the default unattended system prompt
+	MaxIterations int             // Defaults to agents.max_tool_iterations
+}
+
+// RunTurn runs prompt as a self-contained turn with a restricted set of
+// tools. Unlike Run it keeps no history and prints nothing; it returns the
+// model's final reply. It does not conflict with a turn already running.
+func (l *Loop) RunTurn(ctx context.Context, prompt string, opts TurnOptions) (string, error) {
+	registry := opts.Registry
+	if registry == nil {
+		registry = l.tools
+	}
+	registry = registry.Subset(opts.Tools...)
+
+	systemPrompt := opts.SystemPrompt
+	if systemPrompt == "" {
+		systemPrompt = l.buildTurnSystemPrompt(registry, prompt)
+	}
+	messages := []providers.Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: prompt},
+	}
+	toolDefs := toolDefinitions(registry)
+
+	maxIterations := opts.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = l.cfg.Agents.MaxToolIterations
+	}
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		resp, err := l.provider.Chat(ctx, messages, toolDefs, l.cfg.Agents.Model, map[string]interface{}{
+			"max_tokens":  l.cfg.Agents.MaxTokens,
+			"temperature": l.cfg.Agents.Temperature,
+		})
+		if err != nil {
+			return "", fmt.Errorf("LLM call failed: %w", err)
+		}
+
+		if len(resp.ToolCalls) == 0 {
+			return resp.Content, nil
+		}
+
+		assistantMsg := providers.Message{
+			Role:    "assistant",
+			Content: resp.Content,
+		}
+		for _, tc := range resp.ToolCalls {
+			assistantMsg.ToolCalls = append(assistantMsg.ToolCalls, providers.ToolCall{
+				ID:        tc.ID,
+				Type:      "function",
+				Name:      registry.ResolveName(tc.Name),
+				Arguments: tc.Arguments,
+				Function:  tc.Function,
+			})
+		}
+		messages = append(messages, assistantMsg)
+
+		for _, tc := range resp.ToolCalls {
+			logger.DebugCF("agent", fmt.Sprintf("Turn tool: %s", registry.ResolveName(tc.Name)), map[string]interface{}{
+				"args": utils.Truncate(fmt.Sprintf("%v", tc.Arguments), 100),
+			})
+
+			result, _ := l.executeTool(ctx, registry, tc, "agent")
+			messages = append(messages, providers.Message{
+				Role:       "tool",
+				Content:    result,
+				ToolCallID: tc.ID,
+			})
+		}
+	}
+
+	return "", fmt.Errorf("turn did not finish within %d iterations", maxIterations)
+}
+
+// buildTurnSystemPrompt creates the system prompt for an unattended turn.
+func (l *Loop) buildTurnSystemPrompt(registry *tools.Registry, prompt string) string {
+	toolNames := "none"
+	if names := registry.List(); len(names) > 0 {
+		toolNames = strings.Join(names, ", ")
+	}
+
+	basePrompt := fmt.Sprintf(`You are DomiClaw, an AI coding assistant running unattended in the background. Nobody is watching this conversation; your final reply is logged.
+
+You have only these tools: %s
+Do not try to use any other tool. If a task needs a tool you don't have, say so in your reply.
+
+Be brief: report what you did and anything that needs the user's attention.
+`, toolNames)
+
+	if memoryCtx := l.memoryContext(prompt); memoryCtx != "" {
+		basePrompt += "\n---\n\n" + memoryCtx
+	}
+
+	return basePrompt
+}
+
+// toolDefinitions converts a registry's tools into LLM tool definitions.
+func toolDefinitions(registry *tools.Registry) []providers.ToolDefinition {
+	var result []providers.ToolDefinition
+	for _, def := range registry.GetDefinitions() {
+		fn := def["function"].(map[string]interface{})
+		result = append(result, providers.ToolDefinition{
+			Type: "function",
+			Function: providers.ToolFunctionDefinition{
+				Name:        fn["name"].(string),
+				Description: fn["description"].(string),
+				Parameters:  fn["parameters"].(map[string]interface{}),
+			},
+		})
+	}
+	return result
+}
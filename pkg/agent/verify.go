@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/memory"
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// VerifyFileName lists a project's verification commands, one per line,
+// under its .domiclaw directory.
+const VerifyFileName = "verify"
+
+// VerifyResult is the outcome of one verification command.
+type VerifyResult struct {
+	Command  string        `json:"command"`
+	Passed   bool          `json:"passed"`
+	ExitCode int           `json:"exit_code"`
+	Output   string        `json:"output,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// DetectVerifyCommands guesses the build and test commands for the project
+// in dir from its manifest files. It returns nil if it finds none.
+func DetectVerifyCommands(dir string) []string {
+	exists := func(name string) bool {
+		return utils.FileExists(filepath.Join(dir, name))
+	}
+
+	switch {
+	case exists("go.mod"):
+		return []string{"go build ./...", "go vet ./...", "go test ./..."}
+	case exists("Cargo.toml"):
+		return []string{"cargo build", "cargo test"}
+	case exists("package.json"):
+		return npmCommands(filepath.Join(dir, "package.json"))
+	case exists("pyproject.toml") || exists("setup.py") || exists("pytest.ini"):
+		if exists("tests") || exists("test") || exists("pytest.ini") {
+			return []string{"python -m pytest -q"}
+		}
+	case exists("Makefile"):
+		if strings.Contains(utils.ReadFileString(filepath.Join(dir, "Makefile")), "\ntest:") {
+			return []string{"make test"}
+		}
+	}
+	return nil
+}
+
+// npmCommands returns the build and test scripts a package.json defines.
+func npmCommands(path string) []string {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+
+	var commands []string
+	if _, ok := pkg.Scripts["build"]; ok {
+		commands = append(commands, "npm run build")
+	}
+	// npm init's placeholder test script always fails
+	if test, ok := pkg.Scripts["test"]; ok && !strings.Contains(test, "no test specified") {
+		commands = append(commands, "npm test")
+	}
+	return commands
+}
+
+// verifyCommands returns the commands that verify work in the loop's
+// working directory: the project's .domiclaw/verify file, else
+// verify.commands from config, else commands detected from the project.
+func (l *Loop) verifyCommands() []string {
+	dir := l.exec.Workspace
+	root := memory.FindProjectRoot(dir)
+	if root != "" {
+		if content := utils.ReadFileString(filepath.Join(root, ".domiclaw", VerifyFileName)); content != "" {
+			var commands []string
+			for _, line := range strings.Split(content, "\n") {
+				if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+					commands = append(commands, line)
+				}
+			}
+			return commands
+		}
+	}
+
+	if len(l.cfg.Verify.Commands) > 0 {
+		return l.cfg.Verify.Commands
+	}
+	if commands := DetectVerifyCommands(dir); commands != nil {
+		return commands
+	}
+	if root != "" && root != dir {
+		return DetectVerifyCommands(root)
+	}
+	return nil
+}
+
+// verify runs the verification commands in the working directory, in the
+// exec sandbox, stopping at the first failure. It returns nil if
+// verification is disabled or there is nothing to run.
+func (l *Loop) verify(ctx context.Context) []VerifyResult {
+	if !l.cfg.Verify.Enabled {
+		return nil
+	}
+	commands := l.verifyCommands()
+	if len(commands) == 0 {
+		return nil
+	}
+
+	timeout := time.Duration(l.cfg.Verify.TimeoutSeconds) * time.Second
+	var results []VerifyResult
+	for _, command := range commands {
+		fmt.Fprintf(l.out, "\n[verify] %s ... ", command)
+		start := time.Now()
+		output, code, err := l.exec.Run(ctx, l.exec.Workspace, command, timeout)
+		if err != nil {
+			output = strings.TrimRight(output, "\n") + "\n" + err.Error()
+		}
+
+		result := VerifyResult{
+			Command:  command,
+			Passed:   err == nil && code == 0,
+			ExitCode: code,
+			Output:   l.tools.LimitOutput("exec", output),
+			Duration: time.Since(start).Round(100 * time.Millisecond),
+		}
+		results = append(results, result)
+
+		if result.Passed {
+			fmt.Fprintf(l.out, "ok (%s)\n", result.Duration)
+			continue
+		}
+		fmt.Fprintf(l.out, "FAILED (exit %d, %s)\n", code, result.Duration)
+		break
+	}
+	return results
+}
+
+// verificationFailed reports whether any command failed.
+func verificationFailed(results []VerifyResult) bool {
+	for _, r := range results {
+		if !r.Passed {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyFeedback tells the agent why its completion was rejected.
+func verifyFeedback(results []VerifyResult) string {
+	var sb strings.Builder
+	sb.WriteString("You signaled TASK_COMPLETE, but verification failed:\n\n")
+	for _, r := range results {
+		if r.Passed {
+			fmt.Fprintf(&sb, "- `%s` passed\n", r.Command)
+			continue
+		}
+		fmt.Fprintf(&sb, "- `%s` failed (exit code %d):\n\n```\n%s\n```\n", r.Command, r.ExitCode, strings.TrimRight(r.Output, "\n"))
+	}
+	sb.WriteString("\nFix the problems, then signal completion again. Verification runs again automatically.")
+	return sb.String()
+}
+
+// FormatVerification summarizes verification results for a final report,
+// one line per command.
+func FormatVerification(results []VerifyResult) string {
+	if len(results) == 0 {
+		return "Verification: none (no commands configured or detected)\n"
+	}
+	var sb strings.Builder
+	sb.WriteString("Verification:\n")
+	for _, r := range results {
+		status := "passed"
+		if !r.Passed {
+			status = fmt.Sprintf("FAILED, exit %d", r.ExitCode)
+		}
+		fmt.Fprintf(&sb, "  %s (%s, %s)\n", r.Command, status, r.Duration)
+	}
+	return sb.String()
+}
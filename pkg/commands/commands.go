@@ -0,0 +1,181 @@
+// Package commands provides custom slash commands defined in markdown files.
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DomiYoung/domiclaw/pkg/logger"
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// Command scopes.
+const (
+	ScopeUser    = "user"
+	ScopeProject = "project"
+)
+
+// Command is a prompt template run by typing /name in chat. It is defined
+// by name.md in a commands directory, optionally starting with front
+// matter:
+//
+//	---
+//	description: Review a file for bugs
+//	tools: read_file, grep, glob
+//	---
+//	Review $ARGUMENTS for bugs and unclear code.
+type Command struct {
+	Name        string
+	Description string
+	Tools       []string // Tools the agent may use; empty for all
+	Prompt      string   // Template; $ARGUMENTS is replaced by the arguments
+	Scope       string   // ScopeUser or ScopeProject
+	Path        string
+}
+
+// Expand returns the command's prompt for args. If the template doesn't
+// use $ARGUMENTS, non-empty args are appended to it.
+func (c *Command) Expand(args string) string {
+	args = strings.TrimSpace(args)
+	if strings.Contains(c.Prompt, "$ARGUMENTS") {
+		return strings.ReplaceAll(c.Prompt, "$ARGUMENTS", args)
+	}
+	if args == "" {
+		return c.Prompt
+	}
+	return c.Prompt + "\n\nArguments: " + args
+}
+
+// Parse parses a command file's content. name is the command name, from
+// the file name.
+func Parse(name, content string) (*Command, error) {
+	cmd := &Command{Name: name}
+	content = strings.TrimPrefix(strings.ReplaceAll(content, "\r\n", "\n"), "\ufeff")
+	body := content
+
+	if rest, ok := strings.CutPrefix(content, "---\n"); ok {
+		header, after, found := strings.Cut(rest, "\n---")
+		if !found {
+			return nil, fmt.Errorf("command %q: front matter is not closed with ---", name)
+		}
+		body = strings.TrimPrefix(after, "\n")
+		for _, line := range strings.Split(header, "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "description":
+				cmd.Description = strings.Trim(strings.TrimSpace(value), `"'`)
+			case "tools", "allowed-tools", "allowed_tools":
+				cmd.Tools = splitList(value)
+			}
+		}
+	}
+
+	cmd.Prompt = strings.TrimSpace(body)
+	if cmd.Prompt == "" {
+		return nil, fmt.Errorf("command %q has no prompt", name)
+	}
+	if cmd.Description == "" {
+		cmd.Description = utils.Truncate(strings.TrimSpace(strings.SplitN(cmd.Prompt, "\n", 2)[0]), 60)
+	}
+	return cmd, nil
+}
+
+// splitList splits a comma-separated list, also accepting a [a, b] list.
+func splitList(s string) []string {
+	s = strings.Trim(strings.TrimSpace(s), "[]")
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.Trim(strings.TrimSpace(item), `"'`); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// LoadDir loads the commands in dir, one per .md file. A missing directory
+// has no commands; a file that can't be read or parsed is logged and
+// skipped so one bad command doesn't hide the others.
+func LoadDir(dir, scope string) ([]*Command, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var cmds []*Command
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logger.WarnCF("commands", "Skipping unreadable command file", map[string]interface{}{
+				"path":  path,
+				"error": err.Error(),
+			})
+			continue
+		}
+		name := strings.ToLower(strings.TrimSuffix(entry.Name(), ".md"))
+		cmd, err := Parse(name, string(data))
+		if err != nil {
+			logger.WarnCF("commands", "Skipping invalid command file", map[string]interface{}{
+				"path":  path,
+				"error": err.Error(),
+			})
+			continue
+		}
+		cmd.Scope = scope
+		cmd.Path = path
+		cmds = append(cmds, cmd)
+	}
+	return cmds, nil
+}
+
+// Load loads the user commands in userDir and the project commands in
+// projectDir, sorted by name. A project command replaces a user command
+// with the same name.
+func Load(userDir, projectDir string) ([]*Command, error) {
+	byName := make(map[string]*Command)
+	for _, src := range []struct{ dir, scope string }{
+		{userDir, ScopeUser},
+		{projectDir, ScopeProject},
+	} {
+		if src.dir == "" {
+			continue
+		}
+		cmds, err := LoadDir(src.dir, src.scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, cmd := range cmds {
+			byName[cmd.Name] = cmd
+		}
+	}
+
+	cmds := make([]*Command, 0, len(byName))
+	for _, cmd := range byName {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds, nil
+}
+
+// Find returns the command called name, or nil.
+func Find(cmds []*Command, name string) *Command {
+	name = strings.ToLower(name)
+	for _, cmd := range cmds {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
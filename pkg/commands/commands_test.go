@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		description string
+		tools       []string
+		prompt      string
+		wantErr     string
+	}{
+		{
+			name:        "plain prompt",
+			content:     "Explain $ARGUMENTS.\n\nBe brief.\n",
+			description: "Explain $ARGUMENTS.",
+			prompt:      "Explain $ARGUMENTS.\n\nBe brief.",
+		},
+		{
+			name:        "front matter",
+			content:     "---\ndescription: Review a file\ntools: read_file, grep, glob\n---\nReview $ARGUMENTS.\n",
+			description: "Review a file",
+			tools:       []string{"read_file", "grep", "glob"},
+			prompt:      "Review $ARGUMENTS.",
+		},
+		{
+			name:        "allowed-tools as a list",
+			content:     "---\nallowed-tools: [\"read_file\", 'grep']\n---\nLook around.",
+			description: "Look around.",
+			tools:       []string{"read_file", "grep"},
+			prompt:      "Look around.",
+		},
+		{
+			name:        "allowed_tools and quoted description",
+			content:     "---\nDescription: \"Find: usages\"\nallowed_tools: grep,, glob ,\n---\nFind it.",
+			description: "Find: usages",
+			tools:       []string{"grep", "glob"},
+			prompt:      "Find it.",
+		},
+		{
+			name:        "unknown keys and lines are ignored",
+			content:     "---\nmodel: opus\nnot a key\n---\nDo it.",
+			description: "Do it.",
+			prompt:      "Do it.",
+		},
+		{
+			name:        "bom and crlf",
+			content:     "\ufeff---\r\ndescription: Windows file\r\ntools: grep\r\n---\r\nLine one\r\nLine two\r\n",
+			description: "Windows file",
+			tools:       []string{"grep"},
+			prompt:      "Line one\nLine two",
+		},
+		{
+			name:        "long first line is truncated for the description",
+			content:     strings.Repeat("word ", 20),
+			description: strings.Repeat("word ", 12)[:57] + "...",
+			prompt:      strings.TrimSpace(strings.Repeat("word ", 20)),
+		},
+		{
+			name:    "unclosed front matter",
+			content: "---\ndescription: oops\nDo it.",
+			wantErr: "front matter is not closed",
+		},
+		{
+			name:    "no prompt",
+			content: "---\ndescription: empty\n---\n\n",
+			wantErr: "has no prompt",
+		},
+		{
+			name:    "empty file",
+			content: "",
+			wantErr: "has no prompt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := Parse("test", tt.content)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cmd.Name != "test" {
+				t.Errorf("name = %q, want %q", cmd.Name, "test")
+			}
+			if cmd.Description != tt.description {
+				t.Errorf("description = %q, want %q", cmd.Description, tt.description)
+			}
+			if !reflect.DeepEqual(cmd.Tools, tt.tools) {
+				t.Errorf("tools = %q, want %q", cmd.Tools, tt.tools)
+			}
+			if cmd.Prompt != tt.prompt {
+				t.Errorf("prompt = %q, want %q", cmd.Prompt, tt.prompt)
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"read_file, grep", []string{"read_file", "grep"}},
+		{" [read_file,grep] ", []string{"read_file", "grep"}},
+		{`["a", 'b', c]`, []string{"a", "b", "c"}},
+		{"a,,b,", []string{"a", "b"}},
+		{"", nil},
+		{"[]", nil},
+	}
+	for _, tt := range tests {
+		if got := splitList(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitList(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		args   string
+		want   string
+	}{
+		{"placeholder", "Review $ARGUMENTS now", "main.go", "Review main.go now"},
+		{"placeholder twice", "$ARGUMENTS and $ARGUMENTS", "x", "x and x"},
+		{"placeholder without args", "Review $ARGUMENTS", "", "Review "},
+		{"args are trimmed", "Review $ARGUMENTS", "  a.go b.go \n", "Review a.go b.go"},
+		{"args appended", "Summarize the diff", "only tests", "Summarize the diff\n\nArguments: only tests"},
+		{"no args", "Summarize the diff", "  ", "Summarize the diff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &Command{Prompt: tt.prompt}
+			if got := cmd.Expand(tt.args); got != tt.want {
+				t.Errorf("Expand(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	userDir, projectDir := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(userDir, "review.md"):      "User review",
+		filepath.Join(userDir, "Explain.md"):     "Explain $ARGUMENTS",
+		filepath.Join(userDir, "broken.md"):      "---\ndescription: never closed\n",
+		filepath.Join(userDir, "notes.txt"):      "Not a command",
+		filepath.Join(projectDir, "review.md"):   "Project review",
+		filepath.Join(projectDir, "deploy.md"):   "Deploy it",
+		filepath.Join(projectDir, "sub", "x.md"): "In a subdirectory",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmds, err := Load(userDir, projectDir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	var got []string
+	for _, cmd := range cmds {
+		got = append(got, cmd.Name+":"+cmd.Scope+":"+cmd.Prompt)
+	}
+	want := []string{
+		"deploy:project:Deploy it",
+		"explain:user:Explain $ARGUMENTS",
+		"review:project:Project review",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load = %q, want %q", got, want)
+	}
+
+	if cmd := Find(cmds, "EXPLAIN"); cmd == nil || cmd.Name != "explain" {
+		t.Errorf("Find is not case-insensitive: %v", cmd)
+	}
+	if cmd := Find(cmds, "missing"); cmd != nil {
+		t.Errorf("Find(missing) = %v, want nil", cmd)
+	}
+
+	if cmds, err := Load(filepath.Join(userDir, "none"), ""); err != nil || len(cmds) != 0 {
+		t.Errorf("Load of a missing directory = %v, %v; want no commands", cmds, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/DomiYoung/domiclaw/pkg/utils"
@@ -21,6 +22,8 @@ type Config struct {
 	Memory           MemoryConfig    `json:"memory"`
 	Heartbeat        HeartbeatConfig `json:"heartbeat"`
 	StrategicCompact CompactConfig   `json:"strategic_compact"`
+	Verify           VerifyConfig    `json:"verify"`
+	Hooks            HooksConfig     `json:"hooks"`
 }
 
 // AgentsConfig configures agent behavior.
@@ -29,6 +32,10 @@ type AgentsConfig struct {
 	MaxTokens         int     `json:"max_tokens"`
 	Temperature       float64 `json:"temperature"`
 	MaxToolIterations int     `json:"max_tool_iterations"`
+	ContextWindow     int     `json:"context_window"` // Model context size in tokens, for compaction on resume
+	MaxCycles         int     `json:"max_cycles"`     // Autonomous cycles per run before pausing
+	MaxRunTokens      int     `json:"max_run_tokens"` // Token budget per autonomous run; 0 for no limit
+	MaxSubagents      int     `json:"max_subagents"`  // Sub-agents the delegate tool runs at once
 }
 
 // ProvidersConfig configures LLM providers.
@@ -46,30 +53,117 @@ type ProviderConfig struct {
 
 // ToolsConfig configures built-in tools.
 type ToolsConfig struct {
-	Web WebToolsConfig `json:"web"`
+	Web      WebToolsConfig  `json:"web"`
+	Exec     ExecToolsConfig `json:"exec"`
+	ReadFile ReadFileConfig  `json:"read_file"`
+	Output   OutputConfig    `json:"output"`
+}
+
+// ReadFileConfig configures the read_file tool.
+type ReadFileConfig struct {
+	MaxSizeBytes int64 `json:"max_size_bytes"` // Larger files need an explicit offset/limit
+	DefaultLimit int   `json:"default_limit"`  // Lines returned when no limit is given
+}
+
+// OutputConfig limits how much tool output is sent to the model.
+// Larger results keep their head and tail; the full output is saved to a
+// spill file under the workspace.
+type OutputConfig struct {
+	MaxLines int                          `json:"max_lines"`
+	MaxBytes int                          `json:"max_bytes"`
+	PerTool  map[string]OutputLimitConfig `json:"per_tool,omitempty"` // Overrides keyed by tool name
+}
+
+// OutputLimitConfig bounds a single tool's output. Zero means no limit.
+type OutputLimitConfig struct {
+	MaxLines int `json:"max_lines"`
+	MaxBytes int `json:"max_bytes"`
+}
+
+// ExecToolsConfig configures the exec tool.
+type ExecToolsConfig struct {
+	PersistentShell bool          `json:"persistent_shell"` // Keep one bash session per agent (needs bash)
+	Sandbox         SandboxConfig `json:"sandbox"`
+}
+
+// SandboxConfig configures exec sandboxing.
+// Profiles are keyed by run mode: "interactive" (run/chat) and "autonomous" (auto).
+type SandboxConfig struct {
+	Profiles map[string]SandboxProfile `json:"profiles"`
+}
+
+// SandboxProfile configures the sandbox for a single run mode.
+type SandboxProfile struct {
+	Enabled       bool     `json:"enabled"`
+	Backend       string   `json:"backend"`                  // "auto", "bwrap" or "unshare"
+	AllowNetwork  bool     `json:"allow_network"`            // Keep host network access
+	WritablePaths []string `json:"writable_paths,omitempty"` // Extra read-write paths besides the working directory
 }
 
 // WebToolsConfig configures web-related tools.
 type WebToolsConfig struct {
 	Search SearchConfig `json:"search"`
+	Fetch  FetchConfig  `json:"fetch"`
+}
+
+// FetchConfig configures the web_fetch tool.
+type FetchConfig struct {
+	AllowDomains   []string `json:"allow_domains,omitempty"` // If set, only these domains and their subdomains
+	DenyDomains    []string `json:"deny_domains,omitempty"`  // Always blocked, even if allowed
+	MaxBytes       int64    `json:"max_bytes"`
+	TimeoutSeconds int      `json:"timeout_seconds"`
+	MaxRedirects   int      `json:"max_redirects"`
 }
 
 // SearchConfig configures web search.
 type SearchConfig struct {
-	APIKey     string `json:"api_key,omitempty"` // Brave/Tavily API key
-	MaxResults int    `json:"max_results"`
+	Backend         string              `json:"backend,omitempty"`   // "brave", "tavily" or "searxng"; inferred from available keys if empty
+	Fallbacks       []string            `json:"fallbacks,omitempty"` // Backends tried in order when the primary fails
+	MaxResults      int                 `json:"max_results"`
+	CacheTTLSeconds int                 `json:"cache_ttl_seconds"` // 0 disables the result cache
+	Brave           SearchBackendConfig `json:"brave"`
+	Tavily          SearchBackendConfig `json:"tavily"`
+	SearXNG         SearchBackendConfig `json:"searxng"`
+
+	// Deprecated: use Brave.APIKey or Tavily.APIKey. Tavily keys start with "tvly-".
+	APIKey string `json:"api_key,omitempty"`
 }
 
+// SearchBackendConfig configures a single search backend.
+type SearchBackendConfig struct {
+	APIKey  string `json:"api_key,omitempty"`  // Optional: prefer env vars
+	BaseURL string `json:"base_url,omitempty"` // Instance URL (SearXNG)
+}
+
+// SearchBackendNames lists the supported web search backends in the order
+// they are picked when no backend is configured.
+var SearchBackendNames = []string{"brave", "tavily", "searxng"}
+
 // MemoryConfig configures the memory system.
 type MemoryConfig struct {
 	DailyNotesDays         int     `json:"daily_notes_days"`
 	AutoSummarizeThreshold float64 `json:"auto_summarize_threshold"`
+	ContextMode            string  `json:"context_mode"`             // "relevant" (search snippets) or "full" (MEMORY.md + recent notes)
+	ContextResults         int     `json:"context_results"`          // Snippets injected in relevant mode
+	ConsolidateOnHeartbeat bool    `json:"consolidate_on_heartbeat"` // Distill old daily notes on each heartbeat
 }
 
 // HeartbeatConfig configures the heartbeat service.
 type HeartbeatConfig struct {
-	Enabled         bool `json:"enabled"`
-	IntervalSeconds int  `json:"interval_seconds"`
+	Enabled         bool        `json:"enabled"`
+	IntervalSeconds int         `json:"interval_seconds"`
+	QuietHours      string      `json:"quiet_hours,omitempty"` // e.g. "22:00-07:00"; no heartbeats inside this window
+	Tools           []string    `json:"tools,omitempty"`       // Tools for heartbeat turns (default: read-only tools plus note/remember)
+	Jobs            []JobConfig `json:"jobs,omitempty"`        // Scheduled jobs, in addition to workspace/SCHEDULE.md
+}
+
+// JobConfig configures a job run by `domiclaw daemon` on a cron schedule.
+type JobConfig struct {
+	Name      string   `json:"name"`
+	Schedule  string   `json:"schedule"` // Cron expression, e.g. "0 9 * * 1-5" or "@hourly"
+	Prompt    string   `json:"prompt"`
+	Tools     []string `json:"tools,omitempty"`     // Default: same as heartbeat.tools; "*" for all tools
+	Workspace string   `json:"workspace,omitempty"` // Working directory; default: where the daemon runs
 }
 
 // CompactConfig configures strategic compaction.
@@ -78,6 +172,32 @@ type CompactConfig struct {
 	BoundaryPatterns []string `json:"boundary_patterns"`
 }
 
+// VerifyConfig configures the checks run before an autonomous task is
+// accepted as complete.
+type VerifyConfig struct {
+	Enabled        bool     `json:"enabled"`
+	Commands       []string `json:"commands,omitempty"` // Default: detected from the project (go.mod, package.json, ...)
+	TimeoutSeconds int      `json:"timeout_seconds"`    // Per command
+}
+
+// HooksConfig lists commands run at points in the agent lifecycle. Each
+// gets a JSON description of the event on stdin; exit code 2 blocks the
+// action and other output is fed back to the agent.
+type HooksConfig struct {
+	PreTool          []HookConfig `json:"pre_tool,omitempty"`           // Before a tool runs; can block it
+	PostTool         []HookConfig `json:"post_tool,omitempty"`          // After a tool ran; output is added to the result
+	UserPromptSubmit []HookConfig `json:"user_prompt_submit,omitempty"` // Before a prompt is sent; can block it or add context
+	TurnEnd          []HookConfig `json:"turn_end,omitempty"`           // When the agent finishes replying; can make it continue
+	SessionEnd       []HookConfig `json:"session_end,omitempty"`        // When run, chat or auto finishes
+	TimeoutSeconds   int          `json:"timeout_seconds"`              // Per hook
+}
+
+// HookConfig configures one hook command.
+type HookConfig struct {
+	Matcher string `json:"matcher,omitempty"` // Tool name regexp for pre_tool and post_tool; all tools if empty
+	Command string `json:"command"`           // Run with sh -c in the working directory
+}
+
 // DefaultConfig returns the default configuration.
 func DefaultConfig() *Config {
 	home, _ := os.UserHomeDir()
@@ -88,6 +208,9 @@ func DefaultConfig() *Config {
 			MaxTokens:         8192,
 			Temperature:       0.7,
 			MaxToolIterations: 20,
+			ContextWindow:     200000,
+			MaxCycles:         100,
+			MaxSubagents:      4,
 		},
 		Providers: ProvidersConfig{
 			// API keys should come from environment variables
@@ -96,13 +219,42 @@ func DefaultConfig() *Config {
 		Tools: ToolsConfig{
 			Web: WebToolsConfig{
 				Search: SearchConfig{
-					MaxResults: 5,
+					MaxResults:      5,
+					CacheTTLSeconds: 900,
+				},
+				Fetch: FetchConfig{
+					MaxBytes:       2 * 1024 * 1024,
+					TimeoutSeconds: 30,
+					MaxRedirects:   5,
+				},
+			},
+			ReadFile: ReadFileConfig{
+				MaxSizeBytes: 512 * 1024,
+				DefaultLimit: 400,
+			},
+			Output: OutputConfig{
+				MaxLines: 500,
+				MaxBytes: 40 * 1024,
+				PerTool: map[string]OutputLimitConfig{
+					"exec": {MaxLines: 300, MaxBytes: 30 * 1024},
+				},
+			},
+			Exec: ExecToolsConfig{
+				PersistentShell: true,
+				Sandbox: SandboxConfig{
+					Profiles: map[string]SandboxProfile{
+						"interactive": {Enabled: false, Backend: "auto"},
+						// Sandboxing relies on Linux namespaces, so it is only on by default there
+						"autonomous": {Enabled: runtime.GOOS == "linux", Backend: "auto"},
+					},
 				},
 			},
 		},
 		Memory: MemoryConfig{
 			DailyNotesDays:         3,
 			AutoSummarizeThreshold: 0.75,
+			ContextMode:            "relevant",
+			ContextResults:         8,
 		},
 		Heartbeat: HeartbeatConfig{
 			Enabled:         false, // Disabled by default
@@ -117,6 +269,13 @@ func DefaultConfig() *Config {
 				"Checkpoint",
 			},
 		},
+		Verify: VerifyConfig{
+			Enabled:        true,
+			TimeoutSeconds: 600,
+		},
+		Hooks: HooksConfig{
+			TimeoutSeconds: 60,
+		},
 	}
 }
 
@@ -126,6 +285,12 @@ func ConfigPath() string {
 	return filepath.Join(home, ".domiclaw", "config.json")
 }
 
+// CommandsDir returns the directory for the user's custom slash commands,
+// ~/.domiclaw/commands.
+func CommandsDir() string {
+	return filepath.Join(filepath.Dir(ConfigPath()), "commands")
+}
+
 // Load loads configuration from the default path.
 func Load() (*Config, error) {
 	return LoadFrom(ConfigPath())
@@ -178,7 +343,7 @@ func (c *Config) SaveTo(path string) error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return utils.WriteFileAtomic(path, data, 0644)
 }
 
 // WorkspacePath returns the expanded workspace path.
@@ -196,6 +361,35 @@ func (c *Config) SessionsDir() string {
 	return filepath.Join(c.WorkspacePath(), "sessions")
 }
 
+// ToolOutputDir returns the directory for spilled (truncated) tool output.
+func (c *Config) ToolOutputDir() string {
+	return filepath.Join(c.WorkspacePath(), "tool-output")
+}
+
+// TasksDir returns the path to the autonomous task queue.
+func (c *Config) TasksDir() string {
+	return filepath.Join(c.WorkspacePath(), "tasks")
+}
+
+// WorktreesDir returns the directory for git worktrees of autonomous runs.
+func (c *Config) WorktreesDir() string {
+	return filepath.Join(c.WorkspacePath(), "worktrees")
+}
+
+// CheckpointPath returns the path of the autonomous run checkpoint.
+func (c *Config) CheckpointPath() string {
+	return filepath.Join(c.WorkspacePath(), "autonomous-checkpoint.json")
+}
+
+// SandboxProfile returns the exec sandbox profile for a run mode.
+// Unknown modes return a disabled profile.
+func (c *Config) SandboxProfile(mode string) SandboxProfile {
+	if p, ok := c.Tools.Exec.Sandbox.Profiles[mode]; ok {
+		return p
+	}
+	return SandboxProfile{}
+}
+
 // GetAnthropicAPIKey returns the Anthropic API key.
 // Priority: 1. Environment variable, 2. Config file
 func (c *Config) GetAnthropicAPIKey() string {
@@ -268,12 +462,27 @@ func (c *Config) GetHonoursoftAPIBase() string {
 	return ""
 }
 
-// GetSearchAPIKey returns the web search API key.
-// Supports: BRAVE_API_KEY, TAVILY_API_KEY, and TAVILY_API_KEY_1~5 rotation.
-func (c *Config) GetSearchAPIKey() string {
+// GetBraveAPIKey returns the Brave Search API key.
+// Priority: 1. BRAVE_API_KEY, 2. tools.web.search.brave.api_key, 3. legacy api_key
+func (c *Config) GetBraveAPIKey() string {
 	if key := os.Getenv("BRAVE_API_KEY"); key != "" {
 		return key
 	}
+
+	search := c.Tools.Web.Search
+	if search.Brave.APIKey != "" {
+		return search.Brave.APIKey
+	}
+	if search.APIKey != "" && !strings.HasPrefix(search.APIKey, "tvly-") {
+		return search.APIKey
+	}
+
+	return ""
+}
+
+// GetTavilyAPIKey returns the Tavily API key.
+// Supports TAVILY_API_KEY and TAVILY_API_KEY_1~5 rotation, then the config file.
+func (c *Config) GetTavilyAPIKey() string {
 	if key := os.Getenv("TAVILY_API_KEY"); key != "" {
 		return key
 	}
@@ -290,5 +499,69 @@ func (c *Config) GetSearchAPIKey() string {
 		return tavilyKeys[rand.Intn(len(tavilyKeys))]
 	}
 
-	return c.Tools.Web.Search.APIKey
+	search := c.Tools.Web.Search
+	if search.Tavily.APIKey != "" {
+		return search.Tavily.APIKey
+	}
+	if strings.HasPrefix(search.APIKey, "tvly-") {
+		return search.APIKey
+	}
+
+	return ""
+}
+
+// GetSearXNGURL returns the SearXNG instance URL.
+// Priority: 1. SEARXNG_URL, 2. tools.web.search.searxng.base_url
+func (c *Config) GetSearXNGURL() string {
+	if base := os.Getenv("SEARXNG_URL"); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+
+	return strings.TrimRight(c.Tools.Web.Search.SearXNG.BaseURL, "/")
+}
+
+// SearchBackendConfigured reports whether a search backend has the key or URL it needs.
+func (c *Config) SearchBackendConfigured(name string) bool {
+	switch name {
+	case "brave":
+		return c.GetBraveAPIKey() != ""
+	case "tavily":
+		return c.GetTavilyAPIKey() != ""
+	case "searxng":
+		return c.GetSearXNGURL() != ""
+	}
+	return false
+}
+
+// SearchBackends returns the configured search backends in the order they
+// should be tried: the primary backend followed by its fallbacks. Backends
+// without credentials are left out. If no backend is set, the first
+// configured one in SearchBackendNames is used.
+func (c *Config) SearchBackends() []string {
+	search := c.Tools.Web.Search
+
+	var order []string
+	if search.Backend != "" {
+		order = append(order, search.Backend)
+	} else {
+		for _, name := range SearchBackendNames {
+			if c.SearchBackendConfigured(name) {
+				order = append(order, name)
+				break
+			}
+		}
+	}
+	order = append(order, search.Fallbacks...)
+
+	var backends []string
+	seen := make(map[string]bool)
+	for _, name := range order {
+		name = strings.ToLower(name)
+		if seen[name] || !c.SearchBackendConfigured(name) {
+			continue
+		}
+		seen[name] = true
+		backends = append(backends, name)
+	}
+	return backends
 }
@@ -0,0 +1,159 @@
+// Package git provides isolated git worktrees for autonomous runs.
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BranchPrefix starts the names of branches created for autonomous runs.
+const BranchPrefix = "domiclaw/"
+
+// baseConfigKey records a branch's base commit in the repository config,
+// which all worktrees share: branch.<name>.domiclawBase.
+const baseConfigKey = "domiclawBase"
+
+// Worktree is a git worktree on its own branch.
+type Worktree struct {
+	Path   string // Worktree directory
+	Branch string
+	Base   string // Commit the branch started from
+}
+
+// run runs git in dir and returns its trimmed output.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", subcommand(args), msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// subcommand returns the git subcommand in args, skipping "-c key=value".
+func subcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-c" {
+			i++
+			continue
+		}
+		return args[i]
+	}
+	return ""
+}
+
+// RepoRoot returns the top directory of the repository containing dir.
+func RepoRoot(dir string) (string, error) {
+	return run(dir, "rev-parse", "--show-toplevel")
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// BranchName derives a branch name from a task description, e.g.
+// "domiclaw/fix-the-login-bug-20260101-1504".
+func BranchName(task string, now time.Time) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(task), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	if slug == "" {
+		slug = "task"
+	}
+	return BranchPrefix + slug + "-" + now.Format("20060102-1504")
+}
+
+// BranchExists reports whether the repository containing dir has branch.
+func BranchExists(dir, branch string) bool {
+	_, err := run(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// CreateWorktree creates a worktree at path on a new branch starting from
+// the current HEAD of the repository containing dir. The user's checkout,
+// including uncommitted changes, is left alone.
+func CreateWorktree(dir, path, branch string) (*Worktree, error) {
+	root, err := RepoRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	base, err := run(root, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("repository has no commits to branch from: %w", err)
+	}
+	if _, err := run(root, "worktree", "add", "-b", branch, path, base); err != nil {
+		return nil, err
+	}
+	if _, err := run(root, "config", "branch."+branch+"."+baseConfigKey, base); err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return &Worktree{Path: abs, Branch: branch, Base: base}, nil
+}
+
+// OpenWorktree returns the worktree at dir if it is on a branch created by
+// CreateWorktree, or nil if it isn't.
+func OpenWorktree(dir string) *Worktree {
+	branch, err := run(dir, "symbolic-ref", "--short", "HEAD")
+	if err != nil || !strings.HasPrefix(branch, BranchPrefix) {
+		return nil
+	}
+	base, err := run(dir, "config", "branch."+branch+"."+baseConfigKey)
+	if err != nil {
+		return nil
+	}
+	root, err := RepoRoot(dir)
+	if err != nil {
+		return nil
+	}
+	return &Worktree{Path: root, Branch: branch, Base: base}
+}
+
+// Commit stages all changes and commits them. It reports whether there was
+// anything to commit.
+func (w *Worktree) Commit(message string) (bool, error) {
+	if _, err := run(w.Path, "add", "-A"); err != nil {
+		return false, err
+	}
+	status, err := run(w.Path, "status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	if status == "" {
+		return false, nil
+	}
+	args := []string{"commit", "--no-verify", "-q", "-m", message}
+	if email, _ := run(w.Path, "config", "user.email"); email == "" {
+		// Commit anyway on machines without a git identity
+		args = append([]string{"-c", "user.name=DomiClaw", "-c", "user.email=domiclaw@localhost"}, args...)
+	}
+	if _, err := run(w.Path, args...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Log returns the one-line log of the branch's commits.
+func (w *Worktree) Log() (string, error) {
+	return run(w.Path, "log", "--oneline", "--no-decorate", w.Base+"..HEAD")
+}
+
+// DiffStat summarizes the branch's changes against its base.
+func (w *Worktree) DiffStat() (string, error) {
+	return run(w.Path, "diff", "--stat", w.Base, "HEAD")
+}
@@ -0,0 +1,137 @@
+package heartbeat
+
+import (
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"empty", ""},
+		{"four fields", "* * * *"},
+		{"six fields", "* * * * * *"},
+		{"minute out of range", "60 * * * *"},
+		{"hour out of range", "0 24 * * *"},
+		{"day zero", "0 0 0 * *"},
+		{"weekday out of range", "0 0 * * 8"},
+		{"reversed range", "30-10 * * * *"},
+		{"zero step", "*/0 * * * *"},
+		{"bad step", "*/x * * * *"},
+		{"unknown name", "0 0 * foo *"},
+		{"day name in month field", "0 0 * mon *"},
+		{"unknown shortcut", "@fortnightly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSchedule(tt.expr); err == nil {
+				t.Errorf("ParseSchedule(%q) succeeded, want error", tt.expr)
+			}
+		})
+	}
+}
+
+func TestScheduleMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		time string
+		want bool
+	}{
+		{"every minute", "* * * * *", "2026-03-16 13:37", true},
+		{"exact minute", "30 9 * * *", "2026-03-16 09:30", true},
+		{"wrong minute", "30 9 * * *", "2026-03-16 09:31", false},
+
+		{"hour range start", "0 9-17 * * *", "2026-03-16 09:00", true},
+		{"hour range end", "0 9-17 * * *", "2026-03-16 17:00", true},
+		{"hour range outside", "0 9-17 * * *", "2026-03-16 18:00", false},
+
+		{"star step", "*/15 * * * *", "2026-03-16 10:45", true},
+		{"star step off", "*/15 * * * *", "2026-03-16 10:50", false},
+		{"range step", "0-30/10 * * * *", "2026-03-16 10:20", true},
+		{"range step past range", "0-30/10 * * * *", "2026-03-16 10:40", false},
+		{"value step runs to the end", "5/15 * * * *", "2026-03-16 10:50", true},
+		{"value step before start", "5/15 * * * *", "2026-03-16 10:00", false},
+		{"list", "0 0 1,15 * *", "2026-03-15 00:00", true},
+		{"list miss", "0 0 1,15 * *", "2026-03-14 00:00", false},
+
+		{"month names", "0 9 * jan-mar *", "2026-03-16 09:00", true},
+		{"month names outside", "0 9 * jan-mar *", "2026-04-06 09:00", false},
+		{"day names", "0 9 * * mon-fri", "2026-03-16 09:00", true},
+		{"day names weekend", "0 9 * * mon-fri", "2026-03-14 09:00", false},
+		{"names ignore case", "0 9 * MAR MON", "2026-03-16 09:00", true},
+		{"seven is sunday", "0 0 * * 7", "2026-10-18 00:00", true},
+		{"zero is sunday", "0 0 * * 0", "2026-10-18 00:00", true},
+
+		// Both day fields restricted: either may match
+		{"dom or dow: dom matches", "0 0 13 * fri", "2026-04-13 00:00", true},
+		{"dom or dow: dow matches", "0 0 13 * fri", "2026-03-20 00:00", true},
+		{"dom or dow: neither", "0 0 13 * fri", "2026-03-16 00:00", false},
+		// One day field is "*": the other must match
+		{"dom only", "0 0 13 * *", "2026-03-20 00:00", false},
+		{"dow only", "0 0 * * fri", "2026-04-13 00:00", false},
+
+		{"hourly shortcut", "@hourly", "2026-03-16 07:00", true},
+		{"daily shortcut", "@daily", "2026-03-16 07:00", false},
+		{"weekly shortcut", "@weekly", "2026-10-18 00:00", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q): %v", tt.expr, err)
+			}
+			if got := s.Matches(at(tt.time)); got != tt.want {
+				t.Errorf("%q matches %s = %v, want %v", tt.expr, tt.time, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want string // "" if the schedule never fires
+	}{
+		{"next minute", "* * * * *", "2026-03-16 10:07", "2026-03-16 10:08"},
+		{"next step", "*/15 * * * *", "2026-03-16 10:07", "2026-03-16 10:15"},
+		{"strictly after", "0 9 * * *", "2026-03-16 09:00", "2026-03-17 09:00"},
+		{"across month end", "0 0 1 * *", "2026-01-31 10:00", "2026-02-01 00:00"},
+		{"skips short months", "0 12 31 * *", "2026-04-01 00:00", "2026-05-31 12:00"},
+		{"across year end", "@yearly", "2026-12-31 23:59", "2027-01-01 00:00"},
+		{"leap day", "0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		{"weekday after weekend", "0 9 * * mon-fri", "2026-03-13 10:00", "2026-03-16 09:00"},
+		{"dom or dow", "0 0 13 * fri", "2026-03-14 00:00", "2026-03-20 00:00"},
+		{"never", "0 0 31 2 *", "2026-01-01 00:00", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q): %v", tt.expr, err)
+			}
+			got := s.Next(at(tt.from))
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("%q next after %s = %s, want never", tt.expr, tt.from, got)
+				}
+				return
+			}
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("%q next after %s = %s, want %s", tt.expr, tt.from, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// ScheduleFileName is the file in the workspace that defines jobs in
+// addition to those in config.
+const ScheduleFileName = "SCHEDULE.md"
+
+// historyFileName records job runs in the workspace.
+const historyFileName = "schedule-history.json"
+
+// maxHistory bounds the runs kept per job.
+const maxHistory = 20
+
+// Job is a named task run on a cron schedule.
+type Job struct {
+	Name      string
+	Schedule  string   // Cron expression, e.g. "0 9 * * 1-5"
+	Prompt    string   // What the agent should do
+	Tools     []string // Tools the agent may use; empty for the defaults
+	Workspace string   // Working directory for the job; empty for the daemon's
+
+	schedule *Schedule
+}
+
+// Parse validates the job and parses its schedule.
+func (j *Job) Parse() error {
+	if strings.TrimSpace(j.Name) == "" {
+		return fmt.Errorf("job has no name")
+	}
+	if strings.TrimSpace(j.Prompt) == "" {
+		return fmt.Errorf("job %q has no prompt", j.Name)
+	}
+	sched, err := ParseSchedule(j.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", j.Name, err)
+	}
+	j.schedule = sched
+	return nil
+}
+
+// Next returns the next time after t the job is due. The job must have
+// been parsed.
+func (j *Job) Next(t time.Time) time.Time {
+	return j.schedule.Next(t)
+}
+
+// JobRunner runs a job and returns the agent's reply.
+type JobRunner func(ctx context.Context, job *Job) (string, error)
+
+// JobRun is a record of one run of a job.
+type JobRun struct {
+	Job      string        `json:"job"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Status   string        `json:"status"` // "ok" or "error"
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// ParseScheduleFile parses jobs from SCHEDULE.md. Each job is a "## name"
+// section starting with "key: value" lines (schedule, tools, workspace);
+// the rest of the section is the prompt:
+//
+//	## daily-summary
+//	schedule: 0 9 * * 1-5
+//	tools: read_file, recall, note
+//
+//	Summarize yesterday's daily notes and list open follow-ups.
+func ParseScheduleFile(content string) ([]*Job, error) {
+	var jobs []*Job
+	var current *Job
+	var prompt []string
+	inHeader := false
+
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		current.Prompt = strings.TrimSpace(strings.Join(prompt, "\n"))
+		if err := current.Parse(); err != nil {
+			return err
+		}
+		jobs = append(jobs, current)
+		return nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			current = &Job{Name: strings.TrimSpace(line[3:])}
+			prompt = nil
+			inHeader = true
+			continue
+		}
+		if current == nil {
+			continue // Preamble
+		}
+
+		if inHeader {
+			key, value, ok := strings.Cut(line, ":")
+			key = strings.ToLower(strings.TrimSpace(key))
+			switch {
+			case ok && key == "schedule":
+				current.Schedule = strings.TrimSpace(value)
+				continue
+			case ok && key == "tools":
+				current.Tools = splitList(value)
+				continue
+			case ok && key == "workspace":
+				current.Workspace = strings.TrimSpace(value)
+				continue
+			case strings.TrimSpace(line) == "":
+				inHeader = false
+				continue
+			}
+			inHeader = false
+		}
+		prompt = append(prompt, line)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// LoadScheduleFile reads the jobs in the workspace's SCHEDULE.md, if any.
+func LoadScheduleFile(workspace string) ([]*Job, error) {
+	path := filepath.Join(workspace, ScheduleFileName)
+	content := utils.ReadFileString(path)
+	if content == "" {
+		return nil, nil
+	}
+	jobs, err := ParseScheduleFile(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return jobs, nil
+}
+
+// splitList splits a comma-separated list.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// historyPath returns the path of the run history file.
+func historyPath(workspace string) string {
+	return filepath.Join(workspace, historyFileName)
+}
+
+// LoadHistory returns the recorded job runs, oldest first.
+func LoadHistory(workspace string) []JobRun {
+	var runs []JobRun
+	data, err := os.ReadFile(historyPath(workspace))
+	if err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil
+	}
+	return runs
+}
+
+// LastRuns returns the most recent run of each job.
+func LastRuns(runs []JobRun) map[string]JobRun {
+	last := make(map[string]JobRun)
+	for _, run := range runs {
+		if prev, ok := last[run.Job]; !ok || run.Started.After(prev.Started) {
+			last[run.Job] = run
+		}
+	}
+	return last
+}
+
+// recordRun appends a run to the history, keeping the last maxHistory runs
+// of each job.
+func recordRun(workspace string, run JobRun) error {
+	path := historyPath(workspace)
+	lock, err := utils.LockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	runs := append(LoadHistory(workspace), run)
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+
+	// Drop the oldest runs of jobs over the limit
+	count := make(map[string]int)
+	for _, r := range runs {
+		count[r.Job]++
+	}
+	kept := runs[:0]
+	for _, r := range runs {
+		if count[r.Job] > maxHistory {
+			count[r.Job]--
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	data, err := json.MarshalIndent(kept, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, data, 0644)
+}
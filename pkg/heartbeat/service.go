@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,15 +19,66 @@ import (
 // Returns the response and any error.
 type Callback func(prompt string) (string, error)
 
+// NothingToDo is the reply the heartbeat prompt asks for when no task needs
+// attention.
+const NothingToDo = "HEARTBEAT_OK"
+
 // Service manages periodic heartbeat checks.
 type Service struct {
-	workspace   string
-	onHeartbeat Callback
-	interval    time.Duration
-	enabled     bool
-	mu          sync.RWMutex
-	stopChan    chan struct{}
-	running     bool
+	workspace    string
+	onHeartbeat  Callback
+	housekeeping func()
+	interval     time.Duration
+	enabled      bool
+	quiet        *QuietHours
+	jobs         []*Job
+	runJob       JobRunner
+	jobsRunning  map[string]bool
+	beatRunning  bool
+	mu           sync.RWMutex
+	stopChan     chan struct{}
+	running      bool
+}
+
+// QuietHours is a daily time window, possibly spanning midnight, in which
+// heartbeats are skipped.
+type QuietHours struct {
+	Start, End int // Minutes after midnight
+}
+
+var quietHoursRe = regexp.MustCompile(`^(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})$`)
+
+// ParseQuietHours parses a window such as "22:00-07:00".
+func ParseQuietHours(spec string) (*QuietHours, error) {
+	m := quietHoursRe.FindStringSubmatch(strings.TrimSpace(spec))
+	if m == nil {
+		return nil, fmt.Errorf("invalid quiet hours %q: want HH:MM-HH:MM", spec)
+	}
+
+	var minutes [2]int
+	for i := 0; i < 2; i++ {
+		var h, mm int
+		fmt.Sscanf(m[1+2*i], "%d", &h)
+		fmt.Sscanf(m[2+2*i], "%d", &mm)
+		if h > 23 || mm > 59 {
+			return nil, fmt.Errorf("invalid quiet hours %q: bad time", spec)
+		}
+		minutes[i] = h*60 + mm
+	}
+	return &QuietHours{Start: minutes[0], End: minutes[1]}, nil
+}
+
+// Contains reports whether t falls inside the window.
+func (q *QuietHours) Contains(t time.Time) bool {
+	now := t.Hour()*60 + t.Minute()
+	if q.Start <= q.End {
+		return now >= q.Start && now < q.End
+	}
+	return now >= q.Start || now < q.End // Spans midnight
+}
+
+func (q *QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", q.Start/60, q.Start%60, q.End/60, q.End%60)
 }
 
 // NewService creates a new heartbeat service.
@@ -36,9 +89,36 @@ func NewService(workspace string, callback Callback, intervalSec int, enabled bo
 		interval:    time.Duration(intervalSec) * time.Second,
 		enabled:     enabled,
 		stopChan:    make(chan struct{}),
+		jobsRunning: make(map[string]bool),
 	}
 }
 
+// SetQuietHours skips heartbeats inside the given window. Nil disables it.
+func (s *Service) SetQuietHours(q *QuietHours) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quiet = q
+}
+
+// SetHousekeeping sets a function run on every heartbeat outside quiet
+// hours, even when there is nothing for the callback to do. It should be
+// cheap, e.g. memory consolidation that only calls the model when needed.
+func (s *Service) SetHousekeeping(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.housekeeping = fn
+}
+
+// SetJobs schedules parsed jobs; run is called for each job when it is
+// due. Jobs run on their own schedule, even inside quiet hours and when the
+// periodic heartbeat is disabled.
+func (s *Service) SetJobs(jobs []*Job, run JobRunner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = jobs
+	s.runJob = run
+}
+
 // Start starts the heartbeat service.
 func (s *Service) Start(ctx context.Context) error {
 	s.mu.Lock()
@@ -46,9 +126,9 @@ func (s *Service) Start(ctx context.Context) error {
 		s.mu.Unlock()
 		return nil
 	}
-	if !s.enabled {
+	if !s.enabled && len(s.jobs) == 0 {
 		s.mu.Unlock()
-		return fmt.Errorf("heartbeat service is disabled")
+		return fmt.Errorf("heartbeat service is disabled and no jobs are scheduled")
 	}
 	s.running = true
 	s.stopChan = make(chan struct{})
@@ -74,10 +154,29 @@ func (s *Service) Stop() {
 	logger.Info("Heartbeat service stopped")
 }
 
-// runLoop runs the heartbeat check loop.
+// maxJobCatchUp is how far back the loop goes to start jobs for minutes it
+// missed, e.g. while the machine was suspended.
+const maxJobCatchUp = time.Hour
+
+// runLoop runs the heartbeat check loop and, every minute, starts the jobs
+// that are due. Heartbeats and jobs run in their own goroutines so that a
+// slow one doesn't delay the other.
 func (s *Service) runLoop(ctx context.Context) {
-	ticker := time.NewTicker(s.interval)
-	defer ticker.Stop()
+	s.mu.RLock()
+	enabled, interval := s.enabled, s.interval
+	s.mu.RUnlock()
+
+	// A nil channel never fires: jobs only
+	var beats <-chan time.Time
+	if enabled && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		beats = ticker.C
+	}
+
+	next := time.Now().Truncate(time.Minute).Add(time.Minute)
+	minute := time.NewTimer(time.Until(next))
+	defer minute.Stop()
 
 	for {
 		select {
@@ -85,45 +184,199 @@ func (s *Service) runLoop(ctx context.Context) {
 			return
 		case <-s.stopChan:
 			return
-		case <-ticker.C:
-			s.checkHeartbeat()
+		case <-beats:
+			s.startHeartbeat()
+		case <-minute.C:
+			now := time.Now()
+			if oldest := now.Truncate(time.Minute).Add(-maxJobCatchUp); next.Before(oldest) {
+				next = oldest
+			}
+			for ; !next.After(now); next = next.Add(time.Minute) {
+				s.startDueJobs(ctx, next)
+			}
+			minute.Reset(time.Until(next))
 		}
 	}
 }
 
+// startHeartbeat runs a heartbeat check in the background, unless the
+// previous one is still going.
+func (s *Service) startHeartbeat() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.beatRunning {
+		logger.DebugCF("heartbeat", "Skipping heartbeat, previous check still in progress", nil)
+		return
+	}
+	s.beatRunning = true
+	go func() {
+		s.checkHeartbeat()
+		s.mu.Lock()
+		s.beatRunning = false
+		s.mu.Unlock()
+	}()
+}
+
+// startDueJobs starts each job scheduled for the minute containing now,
+// unless its previous run is still going.
+func (s *Service) startDueJobs(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if !job.schedule.Matches(now) {
+			continue
+		}
+		if s.jobsRunning[job.Name] {
+			logger.WarnCF("heartbeat", "Skipping job, previous run still in progress", map[string]interface{}{
+				"job": job.Name,
+			})
+			continue
+		}
+		s.jobsRunning[job.Name] = true
+		go func(job *Job) {
+			s.RunJob(ctx, job)
+			s.mu.Lock()
+			delete(s.jobsRunning, job.Name)
+			s.mu.Unlock()
+		}(job)
+	}
+}
+
+// RunJob runs a job now and records the run in the history.
+func (s *Service) RunJob(ctx context.Context, job *Job) JobRun {
+	s.mu.RLock()
+	runJob := s.runJob
+	s.mu.RUnlock()
+
+	logger.InfoCF("heartbeat", "Running scheduled job", map[string]interface{}{
+		"job": job.Name,
+	})
+
+	run := JobRun{Job: job.Name, Started: time.Now(), Status: "ok"}
+	output, err := runJob(ctx, job)
+	run.Duration = time.Since(run.Started).Round(time.Millisecond)
+	run.Output = utils.Truncate(strings.TrimSpace(output), 2000)
+	if err != nil {
+		run.Status = "error"
+		run.Error = err.Error()
+		logger.ErrorCF("heartbeat", "Scheduled job failed", map[string]interface{}{
+			"job":   job.Name,
+			"error": err.Error(),
+		})
+		s.log(fmt.Sprintf("Job %s error: %v", job.Name, err))
+	}
+
+	if err := recordRun(s.workspace, run); err != nil {
+		logger.WarnCF("heartbeat", "Failed to record job run", map[string]interface{}{
+			"job":   job.Name,
+			"error": err.Error(),
+		})
+	}
+	return run
+}
+
 // checkHeartbeat performs a single heartbeat check.
 func (s *Service) checkHeartbeat() {
 	s.mu.RLock()
-	if !s.enabled || !s.running {
-		s.mu.RUnlock()
-		return
+	active := s.enabled && s.running
+	s.mu.RUnlock()
+
+	if active {
+		s.RunOnce()
 	}
+}
+
+// RunOnce performs a heartbeat now: unless inside quiet hours it runs the
+// housekeeping function and, if HEARTBEAT.md lists open tasks, the callback.
+// It returns the callback's response, or "" if the callback was skipped.
+func (s *Service) RunOnce() (string, error) {
+	s.mu.RLock()
 	callback := s.onHeartbeat
+	housekeeping := s.housekeeping
+	quiet := s.quiet
 	s.mu.RUnlock()
 
+	if quiet != nil && quiet.Contains(time.Now()) {
+		logger.DebugCF("heartbeat", "Skipping heartbeat during quiet hours", map[string]interface{}{
+			"quiet_hours": quiet.String(),
+		})
+		return "", nil
+	}
+
+	if housekeeping != nil {
+		housekeeping()
+	}
+
 	if callback == nil {
-		return
+		return "", nil
 	}
 
-	prompt := s.buildPrompt()
+	// Short-circuit idle beats so they cost nothing
+	notes := s.readNotes()
+	if !HasOpenTasks(notes) {
+		logger.DebugCF("heartbeat", "Nothing to do", nil)
+		return "", nil
+	}
 
 	logger.DebugCF("heartbeat", "Executing heartbeat check", nil)
 
-	_, err := callback(prompt)
+	response, err := callback(s.buildPrompt(notes))
 	if err != nil {
 		logger.ErrorCF("heartbeat", "Heartbeat check failed", map[string]interface{}{
 			"error": err.Error(),
 		})
 		s.log(fmt.Sprintf("Heartbeat error: %v", err))
+		return "", err
+	}
+
+	if IsNothingToDo(response) {
+		logger.DebugCF("heartbeat", "Heartbeat found nothing to do", nil)
+	} else {
+		s.log("Heartbeat: " + utils.Truncate(strings.Join(strings.Fields(response), " "), 200))
 	}
+	return response, nil
 }
 
-// buildPrompt builds the heartbeat prompt.
-func (s *Service) buildPrompt() string {
-	// Read heartbeat notes if they exist
-	notesFile := filepath.Join(s.workspace, "memory", "HEARTBEAT.md")
-	notes := utils.ReadFileString(notesFile)
+// NotesPath returns the path of the heartbeat task list.
+func (s *Service) NotesPath() string {
+	return filepath.Join(s.workspace, "memory", "HEARTBEAT.md")
+}
 
+// readNotes reads the heartbeat task list.
+func (s *Service) readNotes() string {
+	return utils.ReadFileString(s.NotesPath())
+}
+
+var htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// HasOpenTasks reports whether heartbeat notes contain anything to act on:
+// any line other than headings, blank lines, comments and checked-off
+// items ("- [x] ...").
+func HasOpenTasks(notes string) bool {
+	for _, line := range strings.Split(htmlCommentRe.ReplaceAllString(notes, ""), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(strings.ToLower(line), "- [x]"), strings.HasPrefix(strings.ToLower(line), "* [x]"):
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// IsNothingToDo reports whether a heartbeat response means no action was
+// needed.
+func IsNothingToDo(response string) bool {
+	response = strings.TrimSpace(response)
+	return response == "" || strings.HasPrefix(response, NothingToDo) || strings.HasSuffix(response, NothingToDo)
+}
+
+// buildPrompt builds the heartbeat prompt.
+func (s *Service) buildPrompt(notes string) string {
 	now := time.Now().Format("2006-01-02 15:04")
 
 	prompt := fmt.Sprintf(`# Heartbeat Check
@@ -134,7 +387,10 @@ Check if there are any tasks I should be aware of or actions I should take.
 Review the memory file for any important updates or changes.
 Be proactive in identifying potential issues or improvements.
 
-`, now)
+Work through the heartbeat notes below that are due now. If nothing needs
+attention, reply with exactly %s and nothing else.
+
+`, now, NothingToDo)
 
 	if notes != "" {
 		prompt += "## Heartbeat Notes\n\n" + notes
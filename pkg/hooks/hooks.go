@@ -0,0 +1,189 @@
+// Package hooks runs user commands at points in the agent lifecycle.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/config"
+	"github.com/DomiYoung/domiclaw/pkg/logger"
+)
+
+// Hook events.
+const (
+	PreTool          = "pre_tool"
+	PostTool         = "post_tool"
+	UserPromptSubmit = "user_prompt_submit"
+	TurnEnd          = "turn_end"
+	SessionEnd       = "session_end"
+)
+
+// BlockExitCode is the exit code with which a hook blocks the action.
+const BlockExitCode = 2
+
+// Payload describes an event to a hook. It is written to the hook's stdin
+// as JSON; fields that don't apply to the event are omitted.
+type Payload struct {
+	Event      string                 `json:"event"`
+	WorkingDir string                 `json:"working_dir"`
+	Tool       string                 `json:"tool,omitempty"`
+	Input      map[string]interface{} `json:"input,omitempty"`    // Tool arguments
+	Output     string                 `json:"output,omitempty"`   // Tool result (post_tool)
+	IsError    bool                   `json:"is_error,omitempty"` // The tool failed (post_tool)
+	Prompt     string                 `json:"prompt,omitempty"`   // User prompt, or the task in autonomous mode
+	Reply      string                 `json:"reply,omitempty"`    // The agent's last reply (turn_end, session_end)
+	Status     string                 `json:"status,omitempty"`   // How the session ended (session_end)
+}
+
+// Result is the combined outcome of the hooks for an event.
+type Result struct {
+	Blocked  bool   // A hook exited with BlockExitCode
+	Feedback string // The blocking hook's message, or the hooks' stdout
+}
+
+type hook struct {
+	matcher *regexp.Regexp // nil matches every tool
+	command string
+}
+
+// Runner runs the configured hooks.
+type Runner struct {
+	hooks   map[string][]hook
+	dir     string
+	timeout time.Duration
+}
+
+// NewRunner creates a runner for the hooks in cfg, run in dir. Hooks with
+// an invalid matcher are skipped with a warning.
+func NewRunner(cfg config.HooksConfig, dir string) *Runner {
+	r := &Runner{
+		hooks:   make(map[string][]hook),
+		dir:     dir,
+		timeout: time.Duration(cfg.TimeoutSeconds) * time.Second,
+	}
+	if r.timeout <= 0 {
+		r.timeout = 60 * time.Second
+	}
+
+	for event, list := range map[string][]config.HookConfig{
+		PreTool:          cfg.PreTool,
+		PostTool:         cfg.PostTool,
+		UserPromptSubmit: cfg.UserPromptSubmit,
+		TurnEnd:          cfg.TurnEnd,
+		SessionEnd:       cfg.SessionEnd,
+	} {
+		for _, hc := range list {
+			if strings.TrimSpace(hc.Command) == "" {
+				continue
+			}
+			h := hook{command: hc.Command}
+			if hc.Matcher != "" {
+				re, err := regexp.Compile("^(?:" + hc.Matcher + ")$")
+				if err != nil {
+					logger.WarnCF("hooks", "Skipping hook with invalid matcher", map[string]interface{}{
+						"event":   event,
+						"matcher": hc.Matcher,
+						"error":   err.Error(),
+					})
+					continue
+				}
+				h.matcher = re
+			}
+			r.hooks[event] = append(r.hooks[event], h)
+		}
+	}
+	return r
+}
+
+// Has reports whether any hook is configured for event.
+func (r *Runner) Has(event string) bool {
+	return len(r.hooks[event]) > 0
+}
+
+// Run runs the hooks for p.Event in order. It stops at the first hook that
+// blocks; otherwise the stdout of all hooks is collected as feedback. A hook
+// that fails any other way is logged and ignored.
+func (r *Runner) Run(ctx context.Context, p Payload) Result {
+	var result Result
+	hooks := r.hooks[p.Event]
+	if len(hooks) == 0 {
+		return result
+	}
+	if p.WorkingDir == "" {
+		p.WorkingDir = r.dir
+	}
+	input, err := json.Marshal(p)
+	if err != nil {
+		return result
+	}
+
+	var feedback []string
+	for _, h := range hooks {
+		if h.matcher != nil && !h.matcher.MatchString(p.Tool) {
+			continue
+		}
+
+		stdout, stderr, code, err := r.run(ctx, h.command, p, input)
+		switch {
+		case code == BlockExitCode:
+			msg := stderr
+			if msg == "" {
+				msg = stdout
+			}
+			if msg == "" {
+				msg = "blocked by hook: " + h.command
+			}
+			return Result{Blocked: true, Feedback: msg}
+		case err != nil:
+			logger.WarnCF("hooks", "Hook failed", map[string]interface{}{
+				"event":   p.Event,
+				"command": h.command,
+				"error":   err.Error(),
+				"stderr":  stderr,
+			})
+		case stdout != "":
+			feedback = append(feedback, stdout)
+		}
+	}
+	result.Feedback = strings.Join(feedback, "\n")
+	return result
+}
+
+// run runs one hook command with input on stdin. It returns the trimmed
+// output and the exit code, or -1 if the command didn't exit normally.
+func (r *Runner) run(ctx context.Context, command string, p Payload, input []byte) (string, string, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = p.WorkingDir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(),
+		"DOMICLAW_EVENT="+p.Event,
+		"DOMICLAW_TOOL="+p.Tool,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	code := 0
+	if err != nil {
+		code = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			code = exitErr.ExitCode()
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			err = errors.New("timed out after " + r.timeout.String())
+		}
+	}
+	return strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), code, err
+}
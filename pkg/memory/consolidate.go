@@ -0,0 +1,301 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// maxConsolidateChars bounds the notes sent to the summarizer in one request.
+// Months with more notes are consolidated in several batches.
+const maxConsolidateChars = 60000
+
+// SummaryFileName is the monthly summary written next to a month's daily notes.
+const SummaryFileName = "SUMMARY.md"
+
+// consolidateLockName is held for a whole consolidation run, so a second
+// run (say, a heartbeat during a manual one) waits and then finds the notes
+// already archived instead of summarizing them twice.
+const consolidateLockName = ".consolidate.lock"
+
+// archiveDirName holds consolidated daily notes under the memory directory.
+// It is skipped by search and indexing.
+const archiveDirName = "archive"
+
+// Summarizer sends a prompt to a model and returns its reply.
+type Summarizer func(ctx context.Context, prompt string) (string, error)
+
+// Consolidator distills daily notes older than KeepDays into long-term
+// memory facts and monthly summaries, then archives the originals.
+type Consolidator struct {
+	Store     *Store
+	Summarize Summarizer
+	KeepDays  int  // Notes from the last KeepDays days are left alone
+	DryRun    bool // Report what would be consolidated without calling the model
+}
+
+// ConsolidateResult reports what a consolidation run did.
+type ConsolidateResult struct {
+	Notes  []string // Daily notes consolidated (paths relative to the store root)
+	Months []string // Months (YYYYMM) whose summaries were written
+	Facts  int      // New long-term memory entries
+}
+
+// consolidateReply is the JSON the summarizer is asked to return.
+type consolidateReply struct {
+	Summary string `json:"summary"`
+	Facts   []struct {
+		Section string `json:"section"`
+		Fact    string `json:"fact"`
+	} `json:"facts"`
+}
+
+// dailyNoteFile is a daily note on disk.
+type dailyNoteFile struct {
+	path string
+	date time.Time
+}
+
+// Run consolidates every daily note older than KeepDays.
+func (c *Consolidator) Run(ctx context.Context) (*ConsolidateResult, error) {
+	keep := max(c.KeepDays, 1)
+	cutoff := time.Now().AddDate(0, 0, -(keep - 1))
+	cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.Local)
+
+	if !c.DryRun {
+		fl, err := utils.LockFile(filepath.Join(c.Store.memoryDir, consolidateLockName))
+		if err != nil {
+			return nil, err
+		}
+		defer fl.Unlock()
+	}
+
+	byMonth := make(map[string][]dailyNoteFile)
+	for _, note := range c.Store.dailyNoteFiles() {
+		if note.date.Before(cutoff) {
+			month := note.date.Format("200601")
+			byMonth[month] = append(byMonth[month], note)
+		}
+	}
+
+	months := make([]string, 0, len(byMonth))
+	for month := range byMonth {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	result := &ConsolidateResult{}
+	for _, month := range months {
+		for _, batch := range batchNotes(byMonth[month]) {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			if err := c.consolidateBatch(ctx, month, batch, result); err != nil {
+				return result, fmt.Errorf("consolidating %s: %w", month, err)
+			}
+		}
+		if !c.DryRun {
+			result.Months = append(result.Months, month)
+		}
+	}
+
+	return result, nil
+}
+
+// consolidateBatch summarizes a batch of one month's notes, merges the facts
+// into MEMORY.md, appends the summary and archives the notes.
+func (c *Consolidator) consolidateBatch(ctx context.Context, month string, batch []dailyNoteFile, result *ConsolidateResult) error {
+	var notes strings.Builder
+	var rels []string
+	for _, note := range batch {
+		notes.WriteString(fmt.Sprintf("### %s\n\n%s\n\n", note.date.Format("2006-01-02"), strings.TrimSpace(utils.ReadFileString(note.path))))
+		rels = append(rels, c.Store.relPath(note.path))
+	}
+
+	if c.DryRun {
+		result.Notes = append(result.Notes, rels...)
+		return nil
+	}
+
+	reply, err := c.Summarize(ctx, consolidatePrompt(month, c.Store.ReadLongTerm(), notes.String()))
+	if err != nil {
+		return err
+	}
+	parsed, err := parseConsolidateReply(reply)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range parsed.Facts {
+		added, err := c.Store.AppendLongTermSection(f.Section, f.Fact)
+		if err != nil {
+			return err
+		}
+		if added {
+			result.Facts++
+		}
+	}
+
+	first, last := batch[0].date.Format("2006-01-02"), batch[len(batch)-1].date.Format("2006-01-02")
+	if err := c.Store.appendMonthlySummary(month, first, last, parsed.Summary); err != nil {
+		return err
+	}
+
+	// Archive only once the summary is safely written
+	for _, note := range batch {
+		if err := c.Store.archiveNote(note); err != nil {
+			return err
+		}
+	}
+	result.Notes = append(result.Notes, rels...)
+	return nil
+}
+
+// batchNotes splits a month's notes (sorted by date) into batches that fit
+// in one summarizer request.
+func batchNotes(notes []dailyNoteFile) [][]dailyNoteFile {
+	sort.Slice(notes, func(i, j int) bool { return notes[i].date.Before(notes[j].date) })
+
+	var batches [][]dailyNoteFile
+	var current []dailyNoteFile
+	size := 0
+	for _, note := range notes {
+		info, err := os.Stat(note.path)
+		if err != nil {
+			continue
+		}
+		if len(current) > 0 && size+int(info.Size()) > maxConsolidateChars {
+			batches = append(batches, current)
+			current, size = nil, 0
+		}
+		current = append(current, note)
+		size += int(info.Size())
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+func consolidatePrompt(month, longTerm, notes string) string {
+	return fmt.Sprintf(`You are consolidating the daily notes of an AI coding assistant for %s into its long-term memory.
+
+Current long-term memory (MEMORY.md):
+<memory>
+%s
+</memory>
+
+Daily notes:
+<notes>
+%s</notes>
+
+Respond with only a JSON object of this form:
+{"summary": "...", "facts": [{"section": "...", "fact": "..."}]}
+
+- "summary": a markdown summary of the work, decisions and outcomes in these notes (at most about 300 words).
+- "facts": durable facts worth remembering indefinitely - user preferences, project conventions, decisions, lessons learned.
+  One short sentence each. Leave out anything already in long-term memory and anything only relevant to a single day.
+  Use an existing MEMORY.md section title where one fits.`, month, strings.TrimSpace(longTerm), notes)
+}
+
+// parseConsolidateReply extracts the JSON object from the model's reply,
+// tolerating surrounding prose or code fences.
+func parseConsolidateReply(reply string) (*consolidateReply, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("summarizer reply contains no JSON object")
+	}
+
+	var parsed consolidateReply
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse summarizer reply: %w", err)
+	}
+	if strings.TrimSpace(parsed.Summary) == "" {
+		return nil, fmt.Errorf("summarizer reply has an empty summary")
+	}
+	return &parsed, nil
+}
+
+// dailyNoteFiles lists the daily notes (memory/YYYYMM/YYYYMMDD.md) on disk.
+func (s *Store) dailyNoteFiles() []dailyNoteFile {
+	var notes []dailyNoteFile
+	filepath.WalkDir(s.memoryDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == archiveDirName {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if date, ok := dailyNoteDate(d.Name()); ok {
+			notes = append(notes, dailyNoteFile{path: path, date: date})
+		}
+		return nil
+	})
+	return notes
+}
+
+// dailyNoteDate parses the date from a daily note's file name.
+func dailyNoteDate(name string) (time.Time, bool) {
+	if !strings.HasSuffix(name, ".md") {
+		return time.Time{}, false
+	}
+	date, err := time.ParseInLocation("20060102", strings.TrimSuffix(name, ".md"), time.Local)
+	return date, err == nil
+}
+
+// MonthlySummaryPath returns the summary file for a month (YYYYMM).
+func (s *Store) MonthlySummaryPath(month string) string {
+	return filepath.Join(s.memoryDir, month, SummaryFileName)
+}
+
+// appendMonthlySummary adds a summary of the notes from first to last.
+func (s *Store) appendMonthlySummary(month, first, last, summary string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path := s.MonthlySummaryPath(month)
+	existing := utils.ReadFileString(path)
+	if existing == "" {
+		date, _ := time.Parse("200601", month)
+		existing = fmt.Sprintf("# Summary %s\n", date.Format("January 2006"))
+	}
+
+	heading := first
+	if last != first {
+		heading += " to " + last
+	}
+	content := strings.TrimRight(existing, "\n") + fmt.Sprintf("\n\n## %s\n\n%s\n", heading, strings.TrimSpace(summary))
+	return utils.WriteFileString(path, content)
+}
+
+// archiveNote moves a consolidated daily note to memory/archive/YYYYMM/.
+func (s *Store) archiveNote(note dailyNoteFile) error {
+	dir := filepath.Join(s.memoryDir, archiveDirName, note.date.Format("200601"))
+	if err := utils.EnsureDir(dir); err != nil {
+		return err
+	}
+	return os.Rename(note.path, filepath.Join(dir, filepath.Base(note.path)))
+}
+
+// relPath returns path relative to the store root, slash-separated.
+func (s *Store) relPath(path string) string {
+	rel, err := filepath.Rel(s.workspace, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,237 @@
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// DefaultSection is used for long-term entries saved without a section.
+const DefaultSection = "Notes"
+
+// Section is a "## " section of MEMORY.md.
+type Section struct {
+	Title string
+	Body  string
+}
+
+// Match is a memory line matching a search.
+type Match struct {
+	Source  string // File path relative to the store root, e.g. "MEMORY.md"
+	Section string // Enclosing "## " section, if any
+	Line    int    // 1-based line number
+	Text    string
+}
+
+// ParseSections splits markdown into its "## " sections. Text before the
+// first section is returned with an empty title.
+func ParseSections(content string) []Section {
+	var sections []Section
+	current := Section{}
+	var body []string
+
+	flush := func() {
+		current.Body = strings.TrimSpace(strings.Join(body, "\n"))
+		if current.Title != "" || current.Body != "" {
+			sections = append(sections, current)
+		}
+		body = nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if title, ok := sectionTitle(line); ok {
+			flush()
+			current = Section{Title: title}
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+
+	return sections
+}
+
+// sectionTitle returns the title of a "## " heading line.
+func sectionTitle(line string) (string, bool) {
+	if !strings.HasPrefix(line, "## ") {
+		return "", false
+	}
+	return strings.TrimSpace(line[3:]), true
+}
+
+// formatEntry turns text into a single-line "- " bullet.
+func formatEntry(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	text = strings.TrimPrefix(text, "- ")
+	return "- " + text
+}
+
+// AppendLongTermSection adds an entry as a bullet under a "## " section of
+// MEMORY.md, creating the section if needed. It returns false without
+// writing if an identical entry already exists anywhere in the file.
+func (s *Store) AppendLongTermSection(section, entry string) (bool, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	content, added := insertEntry(s.ReadLongTerm(), section, entry)
+	if !added {
+		return false, nil
+	}
+	return true, utils.WriteFileString(s.memoryFile, content)
+}
+
+// insertEntry adds entry under section in content. It reports false if the
+// entry is already present.
+func insertEntry(content, section, entry string) (string, bool) {
+	if strings.TrimSpace(section) == "" {
+		section = DefaultSection
+	}
+	bullet := formatEntry(entry)
+	if bullet == "- " {
+		return content, false
+	}
+
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	for _, line := range lines {
+		if strings.EqualFold(strings.TrimSpace(line), bullet) {
+			return content, false
+		}
+	}
+
+	// Find the section and the last non-blank line before the next heading
+	start := -1
+	for i, line := range lines {
+		if title, ok := sectionTitle(line); ok && strings.EqualFold(title, section) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		if strings.TrimSpace(content) == "" {
+			return fmt.Sprintf("# DomiClaw Memory\n\n## %s\n%s\n", section, bullet), true
+		}
+		return strings.TrimRight(content, "\n") + fmt.Sprintf("\n\n## %s\n%s\n", section, bullet), true
+	}
+
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "# ") || strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
+		}
+	}
+	insertAt := end
+	for insertAt > start+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+	}
+
+	var out []string
+	out = append(out, lines[:insertAt]...)
+	out = append(out, bullet)
+	out = append(out, lines[insertAt:]...)
+	return strings.Join(out, "\n") + "\n", true
+}
+
+// Forget removes long-term memory entries containing text (case-insensitive).
+// If more than one entry matches and all is false, nothing is removed and an
+// error listing the matches is returned.
+func (s *Store) Forget(text string, all bool) ([]string, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return nil, fmt.Errorf("nothing to forget: empty text")
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	lines := strings.Split(s.ReadLongTerm(), "\n")
+	var removed []string
+	var kept []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		isEntry := trimmed != "" && !strings.HasPrefix(trimmed, "#")
+		if isEntry && strings.Contains(strings.ToLower(trimmed), text) {
+			removed = append(removed, trimmed)
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	switch {
+	case len(removed) == 0:
+		return nil, fmt.Errorf("no memory entry matches %q", text)
+	case len(removed) > 1 && !all:
+		return removed, fmt.Errorf("%d entries match %q; be more specific or forget all of them:\n%s",
+			len(removed), text, strings.Join(removed, "\n"))
+	}
+
+	return removed, utils.WriteFileString(s.memoryFile, strings.Join(kept, "\n"))
+}
+
+// Search returns lines of MEMORY.md, the daily notes and monthly summaries
+// that contain every word of query (case-insensitive). Long-term matches come
+// first, then notes from newest to oldest.
+func (s *Store) Search(query string) []Match {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	matches := searchLines(s.relPath(s.memoryFile), s.ReadLongTerm(), terms)
+
+	var notes []string
+	filepath.WalkDir(s.memoryDir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.IsDir() && d.Name() == archiveDirName {
+			return filepath.SkipDir
+		}
+		if err == nil && !d.IsDir() && strings.HasSuffix(path, ".md") {
+			notes = append(notes, path)
+		}
+		return nil
+	})
+	sort.Sort(sort.Reverse(sort.StringSlice(notes)))
+
+	for _, path := range notes {
+		matches = append(matches, searchLines(s.relPath(path), utils.ReadFileString(path), terms)...)
+	}
+
+	return matches
+}
+
+// searchLines returns the lines of content that contain all terms.
+func searchLines(source, content string, terms []string) []Match {
+	var matches []Match
+	section := ""
+	for i, line := range strings.Split(content, "\n") {
+		if title, ok := sectionTitle(line); ok {
+			section = title
+		}
+		lower := strings.ToLower(line)
+		found := strings.TrimSpace(line) != ""
+		for _, term := range terms {
+			if !strings.Contains(lower, term) {
+				found = false
+				break
+			}
+		}
+		if found {
+			matches = append(matches, Match{
+				Source:  source,
+				Section: section,
+				Line:    i + 1,
+				Text:    strings.TrimSpace(line),
+			})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,430 @@
+package memory
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/DomiYoung/domiclaw/pkg/session"
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// BM25 parameters (the usual defaults).
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// maxSnippetLen bounds the snippet returned for a search hit.
+const maxSnippetLen = 400
+
+// Document is a unit of indexed memory: a MEMORY.md section, a section of a
+// daily note or monthly summary, or a message from a saved session.
+type Document struct {
+	Kind   string // "memory", "daily", "summary" or "session"
+	Source string // File path relative to the store root
+	Title  string // Section title or session ID
+	Text   string
+	Date   time.Time // Zero for long-term memory
+}
+
+// Hit is a search result.
+type Hit struct {
+	Doc     *Document
+	Score   float64
+	Snippet string
+}
+
+// Index is an in-memory BM25 index.
+type Index struct {
+	docs   []*indexedDoc
+	df     map[string]int // Documents containing each term
+	avgLen float64
+}
+
+// indexedDoc is a document with its term frequencies.
+type indexedDoc struct {
+	doc    *Document
+	terms  map[string]int
+	length int // Tokens
+}
+
+// NewIndex indexes the given documents.
+func NewIndex(docs []Document) *Index {
+	return newIndex(indexDocuments(docs))
+}
+
+// indexDocuments tokenizes docs, dropping those without any terms.
+func indexDocuments(docs []Document) []*indexedDoc {
+	var out []*indexedDoc
+	for i := range docs {
+		doc := docs[i]
+		tokens := tokenize(doc.Title + "\n" + doc.Text)
+		if len(tokens) == 0 {
+			continue
+		}
+		tf := make(map[string]int)
+		for _, tok := range tokens {
+			tf[tok]++
+		}
+		out = append(out, &indexedDoc{doc: &doc, terms: tf, length: len(tokens)})
+	}
+	return out
+}
+
+func newIndex(docs []*indexedDoc) *Index {
+	idx := &Index{docs: docs, df: make(map[string]int)}
+	total := 0
+	for _, d := range docs {
+		for tok := range d.terms {
+			idx.df[tok]++
+		}
+		total += d.length
+	}
+	if len(docs) > 0 {
+		idx.avgLen = float64(total) / float64(len(docs))
+	}
+	return idx
+}
+
+// BuildIndex indexes the long-term memory and daily notes of each store and
+// the session transcripts saved in sessionsDir (which may be empty). Nil
+// stores are skipped. Files unchanged since the last build are not read
+// again; see indexCache.
+func BuildIndex(sessionsDir string, stores ...*Store) *Index {
+	key := sessionsDir
+	var sources []indexSource
+	for _, s := range stores {
+		if s == nil {
+			continue
+		}
+		key += "\x00" + s.workspace
+		for _, path := range s.sourceFiles() {
+			sources = append(sources, indexSource{path: path, parse: s.fileDocuments})
+		}
+	}
+	if sessionsDir != "" {
+		for _, path := range sessionFiles(sessionsDir) {
+			sources = append(sources, indexSource{path: path, parse: sessionFileDocuments})
+		}
+	}
+	return buildCached(key, sources)
+}
+
+// Len returns the number of indexed documents.
+func (idx *Index) Len() int {
+	return len(idx.docs)
+}
+
+// Search returns up to limit documents ranked by BM25 score.
+func (idx *Index) Search(query string, limit int) []Hit {
+	queryTerms := uniqueTokens(query)
+	if len(queryTerms) == 0 || len(idx.docs) == 0 {
+		return nil
+	}
+
+	n := float64(len(idx.docs))
+	var hits []Hit
+	for _, d := range idx.docs {
+		score := 0.0
+		for _, term := range queryTerms {
+			f := float64(d.terms[term])
+			if f == 0 {
+				continue
+			}
+			df := float64(idx.df[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*float64(d.length)/idx.avgLen
+			score += idf * f * (bm25K1 + 1) / (f + bm25K1*norm)
+		}
+		if score > 0 {
+			hits = append(hits, Hit{Doc: d.doc, Score: score})
+		}
+	}
+
+	sort.SliceStable(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		// Prefer newer documents on ties
+		return hits[a].Doc.Date.After(hits[b].Doc.Date)
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	for i := range hits {
+		hits[i].Snippet = snippet(hits[i].Doc.Text, queryTerms)
+	}
+	return hits
+}
+
+// Documents splits long-term memory and every daily note into documents,
+// one per "## " section.
+func (s *Store) Documents() []Document {
+	var docs []Document
+	for _, path := range s.sourceFiles() {
+		docs = append(docs, s.fileDocuments(path)...)
+	}
+	return docs
+}
+
+// sourceFiles returns the files Documents reads: long-term memory, the daily
+// notes and the monthly summaries of archived notes.
+func (s *Store) sourceFiles() []string {
+	files := []string{s.memoryFile}
+	filepath.WalkDir(s.memoryDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == archiveDirName {
+				return filepath.SkipDir // Already covered by the monthly summaries
+			}
+			return nil
+		}
+		if _, ok := dailyNoteDate(d.Name()); ok || d.Name() == SummaryFileName {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
+
+// fileDocuments splits one of the store's source files into documents.
+func (s *Store) fileDocuments(path string) []Document {
+	var docs []Document
+	if path == s.memoryFile {
+		for _, sec := range ParseSections(s.ReadLongTerm()) {
+			docs = append(docs, Document{Kind: "memory", Source: s.relPath(s.memoryFile), Title: sec.Title, Text: sec.Body})
+		}
+		return docs
+	}
+
+	kind := "daily"
+	date, ok := dailyNoteDate(filepath.Base(path))
+	if !ok {
+		kind = "summary"
+		date, _ = time.ParseInLocation("200601", filepath.Base(filepath.Dir(path)), time.Local)
+	}
+	for _, sec := range ParseSections(utils.ReadFileString(path)) {
+		docs = append(docs, Document{
+			Kind:   kind,
+			Source: s.relPath(path),
+			Title:  sec.Title,
+			Text:   sec.Body,
+			Date:   date,
+		})
+	}
+	return docs
+}
+
+// SessionDocuments turns the user and assistant messages of the session
+// transcripts saved in dir into documents.
+func SessionDocuments(dir string) []Document {
+	var docs []Document
+	for _, path := range sessionFiles(dir) {
+		docs = append(docs, sessionFileDocuments(path)...)
+	}
+	return docs
+}
+
+// sessionFiles returns the session transcripts saved in dir.
+func sessionFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files
+}
+
+// sessionFileDocuments turns one session transcript into documents.
+func sessionFileDocuments(path string) []Document {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var sess session.Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil
+	}
+
+	var docs []Document
+	source := filepath.ToSlash(filepath.Join("sessions", filepath.Base(path)))
+	if sess.Summary != "" {
+		docs = append(docs, Document{Kind: "session", Source: source, Title: sess.ID + " (summary)", Text: sess.Summary, Date: sess.Updated})
+	}
+	for _, msg := range sess.Messages {
+		if (msg.Role != "user" && msg.Role != "assistant") || strings.TrimSpace(msg.Content) == "" {
+			continue
+		}
+		docs = append(docs, Document{
+			Kind:   "session",
+			Source: source,
+			Title:  sess.ID + " (" + msg.Role + ")",
+			Text:   msg.Content,
+			Date:   msg.Timestamp,
+		})
+	}
+	return docs
+}
+
+// snippet returns the run of lines in text that contains the most query terms.
+func snippet(text string, queryTerms []string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	want := make(map[string]bool, len(queryTerms))
+	for _, t := range queryTerms {
+		want[t] = true
+	}
+
+	// Score each line, then pick the best window of up to three lines
+	scores := make([]int, len(lines))
+	for i, line := range lines {
+		for _, tok := range tokenize(line) {
+			if want[tok] {
+				scores[i]++
+			}
+		}
+	}
+	best, bestScore := 0, -1
+	for i := range lines {
+		score := scores[i]
+		if i+1 < len(lines) {
+			score += scores[i+1]
+		}
+		if i+2 < len(lines) {
+			score += scores[i+2]
+		}
+		if score > bestScore && scores[i] > 0 {
+			best, bestScore = i, score
+		}
+	}
+
+	end := min(best+3, len(lines))
+	var parts []string
+	for _, line := range lines[best:end] {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	out := strings.Join(parts, " / ")
+	if runes := []rune(out); len(runes) > maxSnippetLen {
+		out = string(runes[:maxSnippetLen-3]) + "..."
+	}
+	return out
+}
+
+// stopWords are ignored when indexing and searching.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "has": true, "have": true,
+	"in": true, "is": true, "it": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "this": true, "to": true, "was": true, "were": true,
+	"will": true, "with": true, "what": true, "when": true, "how": true, "do": true,
+	"does": true, "did": true, "we": true, "i": true, "you": true, "my": true,
+}
+
+// tokenize lowercases text and splits it into words. Runs of CJK characters,
+// which have no spaces between words, are split into overlapping bigrams.
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 1 || (len(word) == 1 && unicode.IsDigit(word[0])) {
+			if w := string(word); !stopWords[w] {
+				tokens = append(tokens, w)
+			}
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return tokens
+}
+
+// uniqueTokens tokenizes text and removes duplicates, keeping order.
+func uniqueTokens(text string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, tok := range tokenize(text) {
+		if !seen[tok] {
+			seen[tok] = true
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// Location describes where a hit came from, e.g. "MEMORY.md § Preferences".
+func (h Hit) Location() string {
+	loc := h.Doc.Source
+	if h.Doc.Kind == "session" {
+		loc = "session " + h.Doc.Title
+	} else if h.Doc.Title != "" {
+		loc += " § " + h.Doc.Title
+	}
+	if !h.Doc.Date.IsZero() {
+		loc += ", " + h.Doc.Date.Format("2006-01-02")
+	}
+	return loc
+}
+
+// GetRelevantContext returns the memory snippets most relevant to query,
+// formatted for injection into prompts. It returns "" if nothing matches.
+func (s *Store) GetRelevantContext(query, sessionsDir string, limit int) string {
+	hits := BuildIndex(sessionsDir, s).Search(query, limit)
+	if len(hits) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Memory\n\n## Relevant Memory\n\n")
+	for _, h := range hits {
+		sb.WriteString("- [" + h.Location() + "] " + h.Snippet + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// indexCache keeps, for each combination of sources BuildIndex was called
+// with, the last index built and the tokenized documents of every file in
+// it. BuildIndex runs on every prompt and memory search, so it only stats
+// the files and rereads those whose size or modification time changed.
+var indexCache = struct {
+	sync.Mutex
+	sets map[string]*cachedIndex
+}{sets: make(map[string]*cachedIndex)}
+
+type cachedIndex struct {
+	fingerprint string // Paths, sizes and modification times of the files
+	index       *Index
+	files       map[string]*cachedFile
+}
+
+type cachedFile struct {
+	size    int64
+	modTime time.Time
+	docs    []*indexedDoc
+}
+
+// indexSource is a file to index and the function that splits it into
+// documents.
+type indexSource struct {
+	path  string
+	parse func(path string) []Document
+}
+
+// buildCached returns the index of sources, reusing the cached one for key
+// if no file changed and the cached documents of every unchanged file.
+func buildCached(key string, sources []indexSource) *Index {
+	type stat struct {
+		size    int64
+		modTime time.Time
+	}
+	stats := make([]*stat, len(sources))
+	var fp strings.Builder
+	for i, src := range sources {
+		info, err := os.Stat(src.path)
+		if err != nil {
+			continue // E.g. no long-term memory yet
+		}
+		stats[i] = &stat{size: info.Size(), modTime: info.ModTime()}
+		fmt.Fprintf(&fp, "%s\x00%d\x00%d\n", src.path, info.Size(), info.ModTime().UnixNano())
+	}
+
+	indexCache.Lock()
+	defer indexCache.Unlock()
+
+	prev := indexCache.sets[key]
+	if prev != nil && prev.fingerprint == fp.String() {
+		return prev.index
+	}
+
+	// Rebuild, keeping only the current files so deleted ones are dropped
+	set := &cachedIndex{fingerprint: fp.String(), files: make(map[string]*cachedFile)}
+	var docs []*indexedDoc
+	for i, src := range sources {
+		st := stats[i]
+		if st == nil {
+			continue
+		}
+		var cf *cachedFile
+		if prev != nil {
+			cf = prev.files[src.path]
+		}
+		if cf == nil || cf.size != st.size || !cf.modTime.Equal(st.modTime) {
+			cf = &cachedFile{size: st.size, modTime: st.modTime, docs: indexDocuments(src.parse(src.path))}
+		}
+		set.files[src.path] = cf
+		docs = append(docs, cf.docs...)
+	}
+	set.index = newIndex(docs)
+	indexCache.sets[key] = set
+	return set.index
+}
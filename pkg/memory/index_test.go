@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"the cat is on a mat", []string{"cat", "mat"}},
+		{"x y 7 go1.25", []string{"7", "go1", "25"}},
+		{"user_id=42", []string{"user", "id", "42"}},
+		{"Café naïve", []string{"café", "naïve"}},
+		{"数据库迁移", []string{"数据", "据库", "库迁", "迁移"}},
+		{"用 Go 写", []string{"用", "go", "写"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIndexSearchRanking(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		docs  []Document
+		query string
+		want  []string // Titles, best first
+	}{
+		{
+			name: "term frequency",
+			docs: []Document{
+				{Title: "once", Text: "postgres backup notes and more words here"},
+				{Title: "thrice", Text: "postgres postgres postgres backup notes here"},
+			},
+			query: "postgres",
+			want:  []string{"thrice", "once"},
+		},
+		{
+			name: "rare terms weigh more",
+			docs: []Document{
+				{Title: "common", Text: "deploy deploy server"},
+				{Title: "rare", Text: "kubernetes server"},
+				{Title: "filler1", Text: "deploy notes"},
+				{Title: "filler2", Text: "deploy script"},
+			},
+			query: "deploy kubernetes",
+			want:  []string{"rare", "common", "filler1", "filler2"},
+		},
+		{
+			name: "shorter documents win at equal frequency",
+			docs: []Document{
+				{Title: "long", Text: "redis cache eviction policy tuned for large values and many keys"},
+				{Title: "short", Text: "redis cache"},
+			},
+			query: "redis",
+			want:  []string{"short", "long"},
+		},
+		{
+			name: "more query terms matched",
+			docs: []Document{
+				{Title: "one", Text: "alpha gamma"},
+				{Title: "two", Text: "alpha beta"},
+			},
+			query: "alpha beta",
+			want:  []string{"two", "one"},
+		},
+		{
+			name: "titles are indexed",
+			docs: []Document{
+				{Title: "Preferences", Text: "likes tabs"},
+				{Title: "Other", Text: "nothing relevant"},
+			},
+			query: "preferences",
+			want:  []string{"Preferences"},
+		},
+		{
+			name: "newer first on ties",
+			docs: []Document{
+				{Title: "old", Text: "standup notes", Date: day(1)},
+				{Title: "new", Text: "standup notes", Date: day(9)},
+			},
+			query: "standup",
+			want:  []string{"new", "old"},
+		},
+		{
+			name: "cjk bigrams",
+			docs: []Document{
+				{Title: "zh", Text: "数据库迁移计划"},
+				{Title: "en", Text: "database migration plan"},
+			},
+			query: "数据库",
+			want:  []string{"zh"},
+		},
+		{
+			name: "stop words only",
+			docs: []Document{
+				{Title: "a", Text: "the cat"},
+			},
+			query: "the and of",
+			want:  nil,
+		},
+		{
+			name: "no match",
+			docs: []Document{
+				{Title: "a", Text: "something else"},
+			},
+			query: "missing",
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, h := range NewIndex(tt.docs).Search(tt.query, 0) {
+				got = append(got, h.Doc.Title)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexSearchLimitAndSnippet(t *testing.T) {
+	idx := NewIndex([]Document{
+		{Title: "a", Text: "intro\nunrelated line\nthe deploy uses blue green\nrollback is manual\nmore\nlast"},
+		{Title: "b", Text: "deploy"},
+		{Title: "c", Text: "deploy deploy"},
+	})
+	hits := idx.Search("deploy rollback", 1)
+	if len(hits) != 1 || hits[0].Doc.Title != "a" {
+		t.Fatalf("Search with limit 1 = %+v, want document a", hits)
+	}
+	if want := "the deploy uses blue green / rollback is manual / more"; hits[0].Snippet != want {
+		t.Errorf("snippet = %q, want %q", hits[0].Snippet, want)
+	}
+}
+
+func TestBuildIndexCache(t *testing.T) {
+	workspace := t.TempDir()
+	store := NewStore(workspace)
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(workspace, "MEMORY.md"), "## Tools\n\nUses ripgrep.\n")
+	note := filepath.Join(workspace, "memory", "20260301.md")
+	writeFile(note, "## Standup\n\nDiscussed the release.\n")
+
+	first := BuildIndex("", store)
+	if first.Len() != 2 {
+		t.Fatalf("indexed %d documents, want 2", first.Len())
+	}
+	if again := BuildIndex("", store); again != first {
+		t.Error("unchanged files rebuilt the index")
+	}
+
+	// A changed file is read again; the other one comes from the cache
+	writeFile(note, "## Standup\n\nDiscussed the release and the flaky tests.\n")
+	changed := BuildIndex("", store)
+	if changed == first {
+		t.Fatal("changed file did not rebuild the index")
+	}
+	if hits := changed.Search("flaky", 0); len(hits) != 1 {
+		t.Errorf("search for new content found %d hits, want 1", len(hits))
+	}
+	if hits := changed.Search("ripgrep", 0); len(hits) != 1 {
+		t.Errorf("search for cached content found %d hits, want 1", len(hits))
+	}
+
+	// A deleted file drops out
+	if err := os.Remove(note); err != nil {
+		t.Fatal(err)
+	}
+	if removed := BuildIndex("", store); removed.Len() != 1 || len(removed.Search("standup", 0)) != 0 {
+		t.Errorf("deleted file still indexed (%d documents)", removed.Len())
+	}
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// Project memory lives at the root of the repository being worked on.
+// DOMICLAW.md is used when present, so a team can commit it; otherwise
+// long-term memory goes to .domiclaw/MEMORY.md.
+const (
+	ProjectMemoryFileName = "DOMICLAW.md"
+	projectDirName        = ".domiclaw"
+)
+
+// FindProjectRoot returns the root of the git repository containing dir,
+// or "" if dir is not inside one.
+func FindProjectRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if utils.FileExists(filepath.Join(dir, ".git")) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// NewProjectStore creates a memory store for the repository at root.
+// Daily notes go to .domiclaw/memory/. Nothing is created on disk until
+// the first write.
+func NewProjectStore(root string) *Store {
+	memoryFile := filepath.Join(root, ProjectMemoryFileName)
+	if !utils.FileExists(memoryFile) {
+		memoryFile = filepath.Join(root, projectDirName, "MEMORY.md")
+	}
+
+	return &Store{
+		workspace:  root,
+		memoryDir:  filepath.Join(root, projectDirName, "memory"),
+		memoryFile: memoryFile,
+	}
+}
+
+// LongTermPath returns the path of the long-term memory file.
+func (s *Store) LongTermPath() string {
+	return s.memoryFile
+}
+
+// Root returns the directory the store's paths are relative to: the
+// workspace for global memory, the repository root for project memory.
+func (s *Store) Root() string {
+	return s.workspace
+}
+
+// MergeContexts combines project and global memory context, as returned by
+// GetMemoryContext or GetRelevantContext, under separate headers.
+func MergeContexts(project *Store, projectCtx, globalCtx string) string {
+	if projectCtx == "" {
+		return globalCtx
+	}
+	projectCtx = retitle(projectCtx, "Project Memory ("+filepath.Base(project.Root())+")")
+	if globalCtx == "" {
+		return projectCtx
+	}
+	return projectCtx + "\n\n---\n\n" + retitle(globalCtx, "Global Memory")
+}
+
+// retitle replaces the "# Memory" title of a memory context.
+func retitle(ctx, title string) string {
+	return "# " + title + strings.TrimPrefix(ctx, "# Memory")
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/DomiYoung/domiclaw/pkg/utils"
@@ -26,8 +27,15 @@ type Store struct {
 	workspace  string
 	memoryDir  string
 	memoryFile string
+
+	mu sync.Mutex // Serializes mutations within this process; see lock
 }
 
+// lockFileName is the lock file, under the memory directory, that
+// serializes mutations across processes sharing a store (a chat, an
+// autonomous run and the heartbeat, say).
+const lockFileName = ".lock"
+
 // NewStore creates a new memory store with the given workspace path.
 func NewStore(workspace string) *Store {
 	memoryDir := filepath.Join(workspace, "memory")
@@ -52,6 +60,21 @@ func (s *Store) getTodayFile() string {
 	return filepath.Join(s.memoryDir, monthDir, dayFile)
 }
 
+// lock serializes a mutation of the store's files, both within this process
+// and across processes. Callers must call the returned unlock function.
+func (s *Store) lock() (func(), error) {
+	s.mu.Lock()
+	fl, err := utils.LockFile(filepath.Join(s.memoryDir, lockFileName))
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	return func() {
+		fl.Unlock()
+		s.mu.Unlock()
+	}, nil
+}
+
 // ReadLongTerm reads the long-term memory (MEMORY.md).
 func (s *Store) ReadLongTerm() string {
 	return utils.ReadFileString(s.memoryFile)
@@ -59,16 +82,26 @@ func (s *Store) ReadLongTerm() string {
 
 // WriteLongTerm writes content to the long-term memory file.
 func (s *Store) WriteLongTerm(content string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	return utils.WriteFileString(s.memoryFile, content)
 }
 
 // AppendLongTerm appends content to the long-term memory file.
 func (s *Store) AppendLongTerm(content string) error {
-	existing := s.ReadLongTerm()
-	if existing != "" && !strings.HasSuffix(existing, "\n") {
-		existing += "\n"
+	unlock, err := s.lock()
+	if err != nil {
+		return err
 	}
-	return s.WriteLongTerm(existing + content)
+	defer unlock()
+
+	if size, last := fileTail(s.memoryFile); size > 0 && last != '\n' {
+		content = "\n" + content
+	}
+	return utils.AppendFileString(s.memoryFile, content)
 }
 
 // ReadToday reads today's daily note.
@@ -79,22 +112,48 @@ func (s *Store) ReadToday() string {
 // AppendToday appends content to today's daily note.
 // Creates the file with a date header if it doesn't exist.
 func (s *Store) AppendToday(content string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	todayFile := s.getTodayFile()
-	existing := utils.ReadFileString(todayFile)
 
-	var newContent string
-	if existing == "" {
+	// Append rather than rewrite, so a concurrent reader never sees the
+	// note truncated
+	size, last := fileTail(todayFile)
+	switch {
+	case size == 0:
 		// Add header for new day
-		header := fmt.Sprintf("# %s\n\n", time.Now().Format("2006-01-02 Monday"))
-		newContent = header + content
-	} else {
-		if !strings.HasSuffix(existing, "\n") {
-			existing += "\n"
-		}
-		newContent = existing + "\n" + content
+		content = fmt.Sprintf("# %s\n\n", time.Now().Format("2006-01-02 Monday")) + content
+	case last != '\n':
+		content = "\n\n" + content
+	default:
+		content = "\n" + content
+	}
+
+	return utils.AppendFileString(todayFile, content)
+}
+
+// fileTail returns the size of the file at path and its last byte. The
+// size is 0 if the file doesn't exist or can't be read.
+func fileTail(path string) (int64, byte) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0
 	}
+	defer f.Close()
 
-	return utils.WriteFileString(todayFile, newContent)
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return 0, 0
+	}
+	buf := make([]byte, 1)
+	if _, err := f.ReadAt(buf, info.Size()-1); err != nil {
+		return 0, 0
+	}
+	return info.Size(), buf[0]
 }
 
 // GetRecentDailyNotes returns daily notes from the last N days.
@@ -169,6 +228,11 @@ func (s *Store) ResumeTriggerPath() string {
 
 // WriteResumePrompt writes the Gap Analysis result for session recovery.
 func (s *Store) WriteResumePrompt(content string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	return utils.WriteFileString(s.ResumePromptPath(), content)
 }
 
@@ -179,15 +243,22 @@ func (s *Store) ReadResumePrompt() string {
 
 // ClearResumePrompt removes the resume prompt file after successful recovery.
 func (s *Store) ClearResumePrompt() error {
-	path := s.ResumePromptPath()
-	if utils.FileExists(path) {
-		return os.Remove(path)
+	unlock, err := s.lock()
+	if err != nil {
+		return err
 	}
-	return nil
+	defer unlock()
+	return removeIfExists(s.ResumePromptPath())
 }
 
 // WriteResumeTrigger writes the trigger file for session recovery.
 func (s *Store) WriteResumeTrigger(sessionID, reason string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	content := fmt.Sprintf(`{
   "timestamp": "%s",
   "session_id": "%s",
@@ -200,9 +271,19 @@ func (s *Store) WriteResumeTrigger(sessionID, reason string) error {
 
 // ClearResumeTrigger removes the resume trigger file.
 func (s *Store) ClearResumeTrigger() error {
-	path := s.ResumeTriggerPath()
-	if utils.FileExists(path) {
-		return os.Remove(path)
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return removeIfExists(s.ResumeTriggerPath())
+}
+
+// removeIfExists removes path, ignoring a file that is already gone (for
+// example, removed by another process).
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
 	}
 	return nil
 }
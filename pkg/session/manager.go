@@ -19,11 +19,18 @@ type Message struct {
 	Timestamp  time.Time `json:"timestamp"`
 }
 
+// Todo is one item of the agent's todo list at the time the session was saved.
+type Todo struct {
+	Content string `json:"content"`
+	Status  string `json:"status"` // pending, in_progress, done
+}
+
 // Session represents a conversation session.
 type Session struct {
 	ID       string    `json:"id"`
 	Messages []Message `json:"messages"`
 	Summary  string    `json:"summary,omitempty"`
+	Todos    []Todo    `json:"todos,omitempty"`
 	Created  time.Time `json:"created"`
 	Updated  time.Time `json:"updated"`
 }
@@ -134,6 +141,30 @@ func (m *Manager) SetSummary(sessionID, summary string) {
 	}
 }
 
+// GetTodos returns the session's todo list.
+func (m *Manager) GetTodos(sessionID string) []Todo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	return append([]Todo(nil), session.Todos...)
+}
+
+// SetTodos replaces the session's todo list.
+func (m *Manager) SetTodos(sessionID string, todos []Todo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[sessionID]
+	if ok {
+		session.Todos = append([]Todo(nil), todos...)
+		session.Updated = time.Now()
+	}
+}
+
 // TruncateHistory keeps only the last N messages.
 func (m *Manager) TruncateHistory(sessionID string, keepLast int) {
 	m.mu.Lock()
@@ -167,7 +198,7 @@ func (m *Manager) Save(session *Session) error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return utils.WriteFileAtomic(path, data, 0644)
 }
 
 // SaveAll persists all sessions to disk.
@@ -181,7 +212,7 @@ func (m *Manager) SaveAll() error {
 		if err != nil {
 			return err
 		}
-		if err := os.WriteFile(path, data, 0644); err != nil {
+		if err := utils.WriteFileAtomic(path, data, 0644); err != nil {
 			return err
 		}
 	}
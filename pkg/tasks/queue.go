@@ -0,0 +1,320 @@
+// Package tasks provides a file-backed queue of autonomous tasks.
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+// Status is the state of a task.
+type Status string
+
+const (
+	StatusQueued   Status = "queued"
+	StatusRunning  Status = "running"
+	StatusComplete Status = "complete"
+	StatusPaused   Status = "paused" // The agent paused; the task can be resumed
+	StatusFailed   Status = "failed"
+	StatusCanceled Status = "canceled"
+)
+
+// Task is a queued autonomous task.
+type Task struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	WorkingDir  string    `json:"working_dir"` // Directory the task runs in
+	Status      Status    `json:"status"`
+	Created     time.Time `json:"created"`
+	Started     time.Time `json:"started,omitempty"`
+	Finished    time.Time `json:"finished,omitempty"`
+	Attempts    int       `json:"attempts"`          // Times the task was started
+	Cycles      int       `json:"cycles"`            // Cycles used, over all attempts
+	Message     string    `json:"message,omitempty"` // The agent's final message
+	Error       string    `json:"error,omitempty"`
+}
+
+// Done reports whether the task has finished for good.
+func (t *Task) Done() bool {
+	return t.Status == StatusComplete || t.Status == StatusFailed || t.Status == StatusCanceled
+}
+
+// Queue stores tasks as JSON files, one per task, next to their logs:
+//
+//	tasks/
+//	├── 1.json
+//	├── 1.log
+//	├── 1.lock          (held by the worker running task 1)
+//	├── checkpoints/
+//	│   └── 1.json
+//	└── .lock
+type Queue struct {
+	dir string
+}
+
+// NewQueue creates a queue stored in dir.
+func NewQueue(dir string) *Queue {
+	return &Queue{dir: dir}
+}
+
+// lock serializes queue updates across processes (the CLI and workers).
+func (q *Queue) lock() (*utils.FileLock, error) {
+	return utils.LockFile(filepath.Join(q.dir, ".lock"))
+}
+
+func (q *Queue) taskPath(id string) string {
+	return filepath.Join(q.dir, id+".json")
+}
+
+// runLockPath is the lock a worker holds while it runs the task, so that a
+// task left running by a worker that died can be told apart.
+func (q *Queue) runLockPath(id string) string {
+	return filepath.Join(q.dir, id+".lock")
+}
+
+// LogPath returns the path of a task's output log.
+func (q *Queue) LogPath(id string) string {
+	return filepath.Join(q.dir, id+".log")
+}
+
+// CheckpointPath returns the path of a task's autonomous run checkpoint.
+func (q *Queue) CheckpointPath(id string) string {
+	return filepath.Join(q.dir, "checkpoints", id+".json")
+}
+
+// Add queues a new task to run in workingDir.
+func (q *Queue) Add(description, workingDir string) (*Task, error) {
+	lock, err := q.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	all, err := q.list()
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	for _, t := range all {
+		if n, err := strconv.Atoi(t.ID); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+
+	task := &Task{
+		ID:          strconv.Itoa(next),
+		Description: description,
+		WorkingDir:  workingDir,
+		Status:      StatusQueued,
+		Created:     time.Now(),
+	}
+	return task, q.save(task)
+}
+
+// List returns all tasks, oldest first.
+func (q *Queue) List() ([]*Task, error) {
+	return q.list()
+}
+
+func (q *Queue) list() ([]*Task, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var all []*Task
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		task, err := q.load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		all = append(all, task)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].Created.Equal(all[j].Created) {
+			return all[i].Created.Before(all[j].Created)
+		}
+		return all[i].ID < all[j].ID
+	})
+	return all, nil
+}
+
+// Get returns the task with the given ID.
+func (q *Queue) Get(id string) (*Task, error) {
+	task, err := q.load(id)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no task %q", id)
+	}
+	return task, err
+}
+
+func (q *Queue) load(id string) (*Task, error) {
+	data, err := os.ReadFile(q.taskPath(id))
+	if err != nil {
+		return nil, err
+	}
+	var task Task
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, fmt.Errorf("task %s: %w", id, err)
+	}
+	return &task, nil
+}
+
+func (q *Queue) save(task *Task) error {
+	data, err := json.MarshalIndent(task, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(q.taskPath(task.ID), data, 0644)
+}
+
+// update loads a task, applies fn and saves it, under the queue lock.
+func (q *Queue) update(id string, fn func(*Task) error) (*Task, error) {
+	lock, err := q.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	task, err := q.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(task); err != nil {
+		return task, err
+	}
+	return task, q.save(task)
+}
+
+// Cancel cancels a task. A running task is marked canceled and its worker
+// stops it.
+func (q *Queue) Cancel(id string) (*Task, error) {
+	return q.update(id, func(t *Task) error {
+		if t.Done() {
+			return fmt.Errorf("task %s is already %s", t.ID, t.Status)
+		}
+		t.Status = StatusCanceled
+		t.Finished = time.Now()
+		return nil
+	})
+}
+
+// Resume queues a paused or failed task again.
+func (q *Queue) Resume(id string) (*Task, error) {
+	return q.update(id, func(t *Task) error {
+		if t.Status != StatusPaused && t.Status != StatusFailed {
+			return fmt.Errorf("task %s is %s; only paused or failed tasks can be resumed", t.ID, t.Status)
+		}
+		t.Status = StatusQueued
+		t.Error = ""
+		return nil
+	})
+}
+
+// Claim marks the oldest queued task as running and returns it with its
+// run lock, which the caller holds until it has recorded the outcome. It
+// returns nil if the queue is empty.
+func (q *Queue) Claim() (*Task, *utils.FileLock, error) {
+	lock, err := q.lock()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer lock.Unlock()
+
+	all, err := q.list()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, task := range all {
+		if task.Status != StatusQueued {
+			continue
+		}
+		runLock, err := utils.TryLockFile(q.runLockPath(task.ID))
+		if err != nil {
+			return nil, nil, err
+		}
+		if runLock == nil {
+			// A worker is still finishing a previous attempt
+			continue
+		}
+		task.Status = StatusRunning
+		task.Started = time.Now()
+		task.Attempts++
+		if err := q.save(task); err != nil {
+			runLock.Unlock()
+			return nil, nil, err
+		}
+		return task, runLock, nil
+	}
+	return nil, nil, nil
+}
+
+// RecoverStale pauses running tasks whose worker is gone, i.e. nobody holds
+// their run lock, so they can be resumed. It returns the recovered tasks.
+func (q *Queue) RecoverStale() ([]*Task, error) {
+	lock, err := q.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	all, err := q.list()
+	if err != nil {
+		return nil, err
+	}
+	var recovered []*Task
+	for _, task := range all {
+		if task.Status != StatusRunning {
+			continue
+		}
+		runLock, err := utils.TryLockFile(q.runLockPath(task.ID))
+		if err != nil {
+			return recovered, err
+		}
+		if runLock == nil {
+			continue
+		}
+		task.Status = StatusPaused
+		task.Error = "interrupted: worker exited"
+		task.Finished = time.Now()
+		err = q.save(task)
+		runLock.Unlock()
+		if err != nil {
+			return recovered, err
+		}
+		recovered = append(recovered, task)
+	}
+	return recovered, nil
+}
+
+// Finish records the outcome of a run, unless the task was canceled while
+// it ran.
+func (q *Queue) Finish(id string, status Status, cycles int, message, errMsg string) (*Task, error) {
+	return q.update(id, func(t *Task) error {
+		t.Cycles += cycles
+		if message != "" {
+			t.Message = message
+		}
+		if t.Status == StatusCanceled {
+			return nil
+		}
+		t.Status = status
+		t.Error = errMsg
+		t.Finished = time.Now()
+		return nil
+	})
+}
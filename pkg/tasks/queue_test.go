@@ -0,0 +1,174 @@
+package tasks
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DomiYoung/domiclaw/pkg/utils"
+)
+
+func TestQueueLifecycle(t *testing.T) {
+	q := NewQueue(t.TempDir())
+
+	if task, runLock, err := q.Claim(); err != nil || task != nil || runLock != nil {
+		t.Fatalf("Claim on an empty queue = %v, %v, %v; want nothing", task, runLock, err)
+	}
+
+	first, err := q.Add("fix the flaky test", "/src/app")
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	second, err := q.Add("update the docs", "/src/docs")
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if first.ID != "1" || second.ID != "2" || first.Status != StatusQueued {
+		t.Fatalf("Add = %s (%s), %s; want 1 (queued), 2", first.ID, first.Status, second.ID)
+	}
+
+	task, runLock, err := q.Claim()
+	if err != nil || task == nil {
+		t.Fatalf("Claim = %v, %v; want task 1", task, err)
+	}
+	if task.ID != "1" || task.Status != StatusRunning || task.Attempts != 1 || task.Started.IsZero() {
+		t.Errorf("claimed %+v; want task 1 running, attempt 1", task)
+	}
+	if stored, _ := q.Get("1"); stored.Status != StatusRunning {
+		t.Errorf("stored status = %s, want %s", stored.Status, StatusRunning)
+	}
+
+	// A running task whose worker holds the run lock is not stale
+	if recovered, err := q.RecoverStale(); err != nil || len(recovered) != 0 {
+		t.Errorf("RecoverStale = %v, %v; want nothing", recovered, err)
+	}
+
+	done, err := q.Finish(task.ID, StatusComplete, 7, "Fixed it", "")
+	runLock.Unlock()
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if done.Status != StatusComplete || done.Cycles != 7 || done.Message != "Fixed it" || !done.Done() {
+		t.Errorf("finished %+v; want complete after 7 cycles", done)
+	}
+
+	next, runLock, err := q.Claim()
+	if err != nil || next == nil || next.ID != "2" {
+		t.Fatalf("second Claim = %v, %v; want task 2", next, err)
+	}
+	runLock.Unlock()
+	if task, _, err := q.Claim(); err != nil || task != nil {
+		t.Errorf("Claim with nothing queued = %v, %v; want nothing", task, err)
+	}
+
+	// Task 2's worker is gone, so it is paused and can be resumed
+	recovered, err := q.RecoverStale()
+	if err != nil || len(recovered) != 1 || recovered[0].ID != "2" || recovered[0].Status != StatusPaused {
+		t.Fatalf("RecoverStale = %v, %v; want task 2 paused", recovered, err)
+	}
+	if _, err := q.Resume("2"); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	again, runLock, err := q.Claim()
+	if err != nil || again == nil || again.ID != "2" || again.Attempts != 2 {
+		t.Fatalf("Claim after Resume = %+v, %v; want task 2, attempt 2", again, err)
+	}
+	defer runLock.Unlock()
+
+	if _, err := q.Resume("1"); err == nil || !strings.Contains(err.Error(), "only paused or failed") {
+		t.Errorf("Resume of a complete task: error = %v", err)
+	}
+	if _, err := q.Get("9"); err == nil || !strings.Contains(err.Error(), `no task "9"`) {
+		t.Errorf("Get of a missing task: error = %v", err)
+	}
+}
+
+func TestQueueCancelWhileRunning(t *testing.T) {
+	q := NewQueue(t.TempDir())
+	if _, err := q.Add("long task", ""); err != nil {
+		t.Fatal(err)
+	}
+	task, runLock, err := q.Claim()
+	if err != nil || task == nil {
+		t.Fatalf("Claim = %v, %v", task, err)
+	}
+	defer runLock.Unlock()
+
+	if _, err := q.Cancel(task.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	done, err := q.Finish(task.ID, StatusComplete, 3, "", "")
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if done.Status != StatusCanceled || done.Cycles != 3 {
+		t.Errorf("finished %+v; want canceled with 3 cycles", done)
+	}
+	if _, err := q.Cancel(task.ID); err == nil || !strings.Contains(err.Error(), "already canceled") {
+		t.Errorf("second Cancel: error = %v", err)
+	}
+}
+
+// TestQueueClaimRace checks that workers claiming at the same time, each
+// with its own Queue as separate processes would, never get the same task.
+func TestQueueClaimRace(t *testing.T) {
+	const tasks, workers = 5, 12
+
+	dir := t.TempDir()
+	for i := 0; i < tasks; i++ {
+		if _, err := NewQueue(dir).Add("task", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		claimed  = map[string]int{}
+		runLocks []*utils.FileLock
+		wg       sync.WaitGroup
+		start    = make(chan struct{})
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q := NewQueue(dir)
+			<-start
+			task, runLock, err := q.Claim()
+			if err != nil {
+				t.Errorf("Claim: %v", err)
+				return
+			}
+			if task == nil {
+				return
+			}
+			mu.Lock()
+			claimed[task.ID]++
+			runLocks = append(runLocks, runLock)
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+	for _, runLock := range runLocks {
+		runLock.Unlock()
+	}
+
+	if len(claimed) != tasks {
+		t.Errorf("%d tasks claimed, want %d: %v", len(claimed), tasks, claimed)
+	}
+	for id, n := range claimed {
+		if n != 1 {
+			t.Errorf("task %s claimed %d times", id, n)
+		}
+	}
+	all, err := NewQueue(dir).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range all {
+		if task.Status != StatusRunning || task.Attempts != 1 {
+			t.Errorf("task %s is %s after %d attempts; want running after 1", task.ID, task.Status, task.Attempts)
+		}
+	}
+}
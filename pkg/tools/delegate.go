@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Sub-agent access levels.
+const (
+	AccessReadOnly = "read_only"
+	AccessFull     = "full"
+)
+
+// SpawnFunc runs a sub-agent on prompt with a fresh history and the tools
+// for access, and returns its final summary.
+type SpawnFunc func(ctx context.Context, prompt, access string) (string, error)
+
+// DelegateTool hands self-contained tasks to sub-agents so that their
+// exploration stays out of the caller's context. Only each sub-agent's
+// final summary comes back.
+type DelegateTool struct {
+	Spawn         SpawnFunc
+	MaxConcurrent int // Sub-agents running at once; 1 if not positive
+}
+
+func (t *DelegateTool) Name() string { return "delegate" }
+
+func (t *DelegateTool) Description() string {
+	return "Delegate self-contained tasks to sub-agents. Each starts with a fresh context and only its final summary is returned to you, so use this for broad investigation (e.g. \"find every place that parses config and how errors are handled\") that would otherwise fill your context with file contents. " +
+		"Pass several tasks to run them concurrently. Sub-agents cannot see your conversation: write each task so it stands alone and say what the summary should contain. " +
+		"Sub-agents are read-only unless access is \"full\"."
+}
+
+func (t *DelegateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"tasks": map[string]interface{}{
+				"type":        "array",
+				"description": "One prompt per sub-agent; they run concurrently",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+			"access": map[string]interface{}{
+				"type":        "string",
+				"description": "read_only (default): read files, search and the web. full: also write files and run commands",
+				"enum":        []string{AccessReadOnly, AccessFull},
+			},
+		},
+		"required": []string{"tasks"},
+	}
+}
+
+func (t *DelegateTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var prompts []string
+	switch raw := args["tasks"].(type) {
+	case []interface{}:
+		for i, r := range raw {
+			prompt, _ := r.(string)
+			if prompt = strings.TrimSpace(prompt); prompt == "" {
+				return "", fmt.Errorf("tasks[%d] must be a non-empty string", i)
+			}
+			prompts = append(prompts, prompt)
+		}
+	case string:
+		// Some models pass a single task as a string
+		if prompt := strings.TrimSpace(raw); prompt != "" {
+			prompts = append(prompts, prompt)
+		}
+	}
+	if len(prompts) == 0 {
+		return "", fmt.Errorf("tasks must be a non-empty array of strings")
+	}
+
+	access, _ := args["access"].(string)
+	switch access {
+	case "":
+		access = AccessReadOnly
+	case AccessReadOnly, AccessFull:
+	default:
+		return "", fmt.Errorf("invalid access %q (use read_only or full)", access)
+	}
+
+	limit := t.MaxConcurrent
+	if limit <= 0 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	replies := make([]string, len(prompts))
+	errs := make([]error, len(prompts))
+
+	var wg sync.WaitGroup
+	for i, prompt := range prompts {
+		wg.Add(1)
+		go func(i int, prompt string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			replies[i], errs[i] = t.Spawn(ctx, prompt, access)
+		}(i, prompt)
+	}
+	wg.Wait()
+
+	if len(prompts) == 1 {
+		if errs[0] != nil {
+			return "", fmt.Errorf("sub-agent failed: %w", errs[0])
+		}
+		return replies[0], nil
+	}
+
+	var sb strings.Builder
+	for i, prompt := range prompts {
+		fmt.Fprintf(&sb, "## Sub-agent %d: %s\n\n", i+1, firstLine(prompt, 80))
+		if errs[i] != nil {
+			fmt.Fprintf(&sb, "Error: %v\n\n", errs[i])
+			continue
+		}
+		sb.WriteString(strings.TrimSpace(replies[i]) + "\n\n")
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// firstLine returns the first line of s, cut to at most max bytes.
+func firstLine(s string, max int) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > max {
+		s = s[:max-3] + "..."
+	}
+	return s
+}
@@ -68,6 +68,8 @@ func (t *EditFileTool) Execute(ctx context.Context, args map[string]interface{})
 		replaceAll = ra
 	}
 
+	path = resolvePath(t.Workspace, path)
+
 	// Security: ensure path is within workspace
 	if t.Workspace != "" {
 		absPath, err := filepath.Abs(path)
@@ -4,9 +4,11 @@ package tools
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,8 +16,18 @@ import (
 type ExecTool struct {
 	Workspace string
 	Timeout   time.Duration
+	Sandbox   *Sandbox        // Optional; nil runs commands unconfined. Use SetSandbox once in use
+	Shell     *ShellSession   // Optional; nil runs each command in a fresh sh -c
+	Processes *ProcessManager // Optional; enables background=true
+
+	mu      sync.Mutex                 // Guards Sandbox and the fields below
+	running map[int]context.CancelFunc // Foreground commands, for Interrupt
+	nextID  int
 }
 
+// maxExecTimeout caps the per-command timeout the model can request.
+const maxExecTimeout = 10 * time.Minute
+
 // NewExecTool creates a new exec tool.
 func NewExecTool(workspace string) *ExecTool {
 	return &ExecTool{
@@ -27,9 +39,39 @@ func NewExecTool(workspace string) *ExecTool {
 func (t *ExecTool) Name() string { return "exec" }
 
 func (t *ExecTool) Description() string {
+	if t.Shell != nil {
+		return `Execute a shell command in a persistent bash session and return its output, exit code and working directory.
+State carries over between calls: cd, exported variables, activated virtualenvs and shell functions persist.
+Use "reset" to start a fresh session. Use for running build commands, git operations, etc.`
+	}
 	return "Execute a shell command and return its output. Use for running build commands, git operations, etc."
 }
 
+// SetSandbox changes the sandbox policy. A running shell session is reset
+// so the new policy applies to the next command.
+func (t *ExecTool) SetSandbox(sandbox *Sandbox) {
+	t.mu.Lock()
+	t.Sandbox = sandbox
+	t.mu.Unlock()
+	if t.Shell != nil {
+		t.Shell.Reset()
+	}
+}
+
+// CurrentSandbox returns the sandbox policy commands run under, nil if none.
+func (t *ExecTool) CurrentSandbox() *Sandbox {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Sandbox
+}
+
+// Close terminates the persistent shell session, if any.
+func (t *ExecTool) Close() {
+	if t.Shell != nil {
+		t.Shell.Close()
+	}
+}
+
 func (t *ExecTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
@@ -40,7 +82,19 @@ func (t *ExecTool) Parameters() map[string]interface{} {
 			},
 			"workdir": map[string]interface{}{
 				"type":        "string",
-				"description": "Working directory for the command (optional)",
+				"description": "Working directory for this command only (optional)",
+			},
+			"timeout": map[string]interface{}{
+				"type":        "integer",
+				"description": "Timeout in seconds (optional, default 120, max 600)",
+			},
+			"background": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Start the command in the background and return a process id immediately (for dev servers, watchers). Use process_output/process_input/process_list/process_kill to manage it",
+			},
+			"reset": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Restart the shell session before running the command (optional)",
 			},
 		},
 		"required": []string{"command"},
@@ -67,6 +121,13 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) (st
 		return "", fmt.Errorf("command must be a string")
 	}
 
+	if reset, _ := args["reset"].(bool); reset && t.Shell != nil {
+		t.Shell.Reset()
+		if strings.TrimSpace(command) == "" {
+			return fmt.Sprintf("Shell session reset.\n[cwd: %s]", t.Shell.Cwd()), nil
+		}
+	}
+
 	// Security: check for dangerous commands
 	cmdLower := strings.ToLower(command)
 	for _, pattern := range dangerousPatterns {
@@ -75,44 +136,204 @@ func (t *ExecTool) Execute(ctx context.Context, args map[string]interface{}) (st
 		}
 	}
 
+	timeout := t.Timeout
+	if secs, ok := args["timeout"].(float64); ok && secs > 0 {
+		timeout = time.Duration(secs) * time.Second
+		if timeout > maxExecTimeout {
+			timeout = maxExecTimeout
+		}
+	}
+
 	workdir := t.Workspace
+	if t.Shell != nil {
+		workdir = t.Shell.Cwd()
+	}
+
+	if background, _ := args["background"].(bool); background {
+		if wd, ok := args["workdir"].(string); ok && wd != "" {
+			workdir = wd
+		}
+		return t.executeInBackground(command, workdir)
+	}
+
 	if wd, ok := args["workdir"].(string); ok && wd != "" {
 		workdir = wd
+		if t.Shell != nil {
+			// Run in a subshell so an explicit workdir doesn't move the session
+			command = fmt.Sprintf("(\ncd %s || exit 1\n%s\n)", shellQuote(wd), command)
+		}
+	}
+
+	ctx, done := t.track(ctx)
+	defer done()
+
+	if t.Shell != nil {
+		return t.executeInShell(ctx, command, timeout)
 	}
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Execute command
-	cmd := exec.CommandContext(ctx, "sh", "-c", command)
-	cmd.Dir = workdir
+	// Execute command (inside the sandbox if one is configured)
+	cmd, err := t.CurrentSandbox().Command(ctx, workdir, "sh", "-c", command)
+	if err != nil {
+		return "", err
+	}
+
+	killOnCancel(cmd)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	// A nonzero exit is reported in the result, as in the persistent shell;
+	// errors are for commands that could not run or timed out
+	err = cmd.Run()
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return formatOutput(stdout.String(), stderr.String()), fmt.Errorf("command timed out after %v", timeout)
+	case context.Canceled:
+		return formatOutput(stdout.String(), stderr.String()), fmt.Errorf("command interrupted")
+	}
+	exitCode := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		return formatOutput(stdout.String(), stderr.String()), fmt.Errorf("command failed: %w", err)
+	}
+	return formatExecResult(stdout.String(), stderr.String(), exitCode, workdir), nil
+}
 
-	// Build result
-	var result strings.Builder
-	if stdout.Len() > 0 {
-		result.WriteString(stdout.String())
+// killOnCancel makes cmd's context kill its whole process group, so that a
+// timeout isn't held up by children that keep the output pipes open.
+func killOnCancel(cmd *exec.Cmd) {
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		killProcessGroup(cmd)
+		return nil
 	}
-	if stderr.Len() > 0 {
+	cmd.WaitDelay = time.Second
+}
+
+// formatOutput combines a command's stdout and stderr for the model.
+func formatOutput(stdout, stderr string) string {
+	var result strings.Builder
+	result.WriteString(stdout)
+	if stderr != "" {
 		if result.Len() > 0 {
 			result.WriteString("\n")
 		}
 		result.WriteString("stderr:\n")
-		result.WriteString(stderr.String())
+		result.WriteString(stderr)
+	}
+	return result.String()
+}
+
+// formatExecResult formats the output of a finished command followed by its
+// exit code and the working directory it ended in.
+func formatExecResult(stdout, stderr string, exitCode int, cwd string) string {
+	result := formatOutput(stdout, stderr)
+	if result != "" && !strings.HasSuffix(result, "\n") {
+		result += "\n"
 	}
+	return result + fmt.Sprintf("[exit code: %d, cwd: %s]", exitCode, cwd)
+}
+
+// Interrupt kills the foreground commands that are running, e.g. when the
+// agent is stopped. Background processes are left running.
+func (t *ExecTool) Interrupt() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, cancel := range t.running {
+		cancel()
+	}
+}
+
+// track returns a context for a foreground command that Interrupt cancels,
+// and a function to call when the command has finished.
+func (t *ExecTool) track(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
 
+	t.mu.Lock()
+	if t.running == nil {
+		t.running = make(map[int]context.CancelFunc)
+	}
+	id := t.nextID
+	t.nextID++
+	t.running[id] = cancel
+	t.mu.Unlock()
+
+	return ctx, func() {
+		t.mu.Lock()
+		delete(t.running, id)
+		t.mu.Unlock()
+		cancel()
+	}
+}
+
+// Run runs command with sh -c in workdir, inside the sandbox if one is set,
+// and returns its combined output and exit code. Unlike Execute it never
+// uses the persistent shell, so the result doesn't depend on session state.
+// err is only set if the command could not be run or timed out.
+func (t *ExecTool) Run(ctx context.Context, workdir, command string, timeout time.Duration) (string, int, error) {
+	if timeout <= 0 {
+		timeout = t.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd, err := t.CurrentSandbox().Command(ctx, workdir, "sh", "-c", command)
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return result.String(), fmt.Errorf("command timed out after %v", t.Timeout)
-		}
-		return result.String(), fmt.Errorf("command failed: %w", err)
+		return "", -1, err
 	}
+	killOnCancel(cmd)
 
-	return result.String(), nil
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return output.String(), -1, fmt.Errorf("command timed out after %v", timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return output.String(), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return output.String(), -1, err
+	}
+	return output.String(), 0, nil
+}
+
+// executeInBackground starts command as a background process.
+func (t *ExecTool) executeInBackground(command, workdir string) (string, error) {
+	if t.Processes == nil {
+		return "", fmt.Errorf("background processes are not enabled")
+	}
+
+	p, err := t.Processes.Start(t.CurrentSandbox(), workdir, command)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Started background process %s (pid %d) in %s.\nUse process_output with id %q to check its output.",
+		p.ID, p.cmd.Process.Pid, workdir, p.ID), nil
+}
+
+// executeInShell runs command in the persistent shell session.
+func (t *ExecTool) executeInShell(ctx context.Context, command string, timeout time.Duration) (string, error) {
+	res, err := t.Shell.Run(ctx, t.CurrentSandbox(), command, timeout)
+	if res == nil {
+		return "", err
+	}
+	if errors.Is(err, context.Canceled) {
+		err = fmt.Errorf("command interrupted (shell session was reset)")
+	}
+	if err != nil {
+		return formatOutput(res.Stdout, res.Stderr), err
+	}
+	return formatExecResult(res.Stdout, res.Stderr, res.ExitCode, res.Cwd), nil
 }
@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExecExitCodes checks that the persistent shell and the sh -c fallback
+// report command results the same way: a nonzero exit is part of the
+// output, not an error.
+func TestExecExitCodes(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		wantErr string
+	}{
+		{"success", "echo hello", []string{"hello\n[exit code: 0, cwd: " + dir + "]"}, ""},
+		{"nonzero exit", "echo partial; echo oops >&2; sh -c 'exit 3'", []string{"partial\n", "stderr:\noops\n", "[exit code: 3, cwd: " + dir + "]"}, ""},
+		{"no output", "true", []string{"[exit code: 0, cwd: " + dir + "]"}, ""},
+		{"timeout", "sleep 5", nil, "timed out"},
+	}
+	for _, shell := range []bool{true, false} {
+		for _, tt := range tests {
+			name := tt.name + "/sh -c"
+			if shell {
+				name = tt.name + "/shell"
+			}
+			t.Run(name, func(t *testing.T) {
+				tool := NewExecTool(dir)
+				if shell {
+					tool.Shell = NewShellSession(dir)
+					defer tool.Shell.Close()
+				}
+				got, err := tool.Execute(context.Background(), map[string]interface{}{
+					"command": tt.command,
+					"timeout": float64(1),
+				})
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v (output %q)", err, got)
+				}
+				for _, w := range tt.want {
+					if !strings.Contains(got, w) {
+						t.Errorf("output %q does not contain %q", got, w)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestExecInterrupt(t *testing.T) {
+	for _, shell := range []bool{true, false} {
+		name := "sh -c"
+		if shell {
+			name = "shell"
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			tool := NewExecTool(dir)
+			if shell {
+				tool.Shell = NewShellSession(dir)
+				defer tool.Shell.Close()
+			}
+
+			type result struct {
+				output string
+				err    error
+			}
+			done := make(chan result, 1)
+			go func() {
+				out, err := tool.Execute(context.Background(), map[string]interface{}{
+					"command": "echo started; sleep 30",
+				})
+				done <- result{out, err}
+			}()
+
+			time.Sleep(300 * time.Millisecond)
+			tool.Interrupt()
+			select {
+			case r := <-done:
+				if r.err == nil || !strings.Contains(r.err.Error(), "interrupted") {
+					t.Errorf("error = %v, want interrupted", r.err)
+				}
+				if !strings.Contains(r.output, "started") {
+					t.Errorf("output %q lost what was printed before the interrupt", r.output)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("command still running after Interrupt")
+			}
+		})
+	}
+}
@@ -2,20 +2,41 @@
 package tools
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/binary"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // ReadFileTool reads file contents.
-type ReadFileTool struct{}
+type ReadFileTool struct {
+	Workspace    string   // Reads are confined to this directory (if set)
+	AllowedDirs  []string // Additional readable directories
+	MaxBytes     int64    // Files larger than this need an explicit range (0 = no limit)
+	DefaultLimit int      // Lines returned when no limit is given (0 = all)
+}
+
+const (
+	// maxReadLineLength truncates very long lines (minified code, data files).
+	maxReadLineLength = 2000
+	// binarySniffLen is how much of a file is inspected for binary content.
+	binarySniffLen = 8000
+)
 
 func (t *ReadFileTool) Name() string { return "read_file" }
 
 func (t *ReadFileTool) Description() string {
-	return "Read the contents of a file at the given path. Returns the file content as text."
+	return `Read a text file. Returns lines prefixed with their line numbers (use these to pick edit_file targets; the prefix is not part of the file).
+Use offset (1-based line number) and limit to read a range of a large file.
+Binary files are detected and not displayed.`
 }
 
 func (t *ReadFileTool) Parameters() map[string]interface{} {
@@ -26,6 +47,14 @@ func (t *ReadFileTool) Parameters() map[string]interface{} {
 				"type":        "string",
 				"description": "The path to the file to read",
 			},
+			"offset": map[string]interface{}{
+				"type":        "integer",
+				"description": "Line number to start reading from (1-based, optional)",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum number of lines to read (optional)",
+			},
 		},
 		"required": []string{"path"},
 	}
@@ -37,12 +66,187 @@ func (t *ReadFileTool) Execute(ctx context.Context, args map[string]interface{})
 		return "", fmt.Errorf("path must be a string")
 	}
 
-	content, err := os.ReadFile(path)
+	offset := 1
+	if o, ok := args["offset"].(float64); ok && o >= 1 {
+		offset = int(o)
+	}
+	limit := 0
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+	hasRange := offset > 1 || limit > 0
+	if limit == 0 {
+		limit = t.DefaultLimit
+	}
+
+	path = resolvePath(t.Workspace, path)
+
+	// Security: ensure path is within workspace or an allowed directory
+	if t.Workspace != "" {
+		roots := append([]string{t.Workspace}, t.AllowedDirs...)
+		if !pathWithin(path, roots...) {
+			return "", fmt.Errorf("path must be within workspace")
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory; use list_dir", path)
+	}
+	if t.MaxBytes > 0 && info.Size() > t.MaxBytes && !hasRange {
+		return "", fmt.Errorf("file is too large (%d bytes, max %d). Use offset and limit to read a range", info.Size(), t.MaxBytes)
+	}
+
+	f, err := os.Open(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	head, _ := reader.Peek(binarySniffLen)
+	if len(head) == 0 {
+		return "(empty file)", nil
+	}
+
+	// UTF-16 files (BOM-marked) are decoded whole
+	if bytes.HasPrefix(head, []byte{0xFF, 0xFE}) || bytes.HasPrefix(head, []byte{0xFE, 0xFF}) {
+		if t.MaxBytes > 0 && info.Size() > t.MaxBytes {
+			return "", fmt.Errorf("UTF-16 file is too large to decode (%d bytes, max %d)", info.Size(), t.MaxBytes)
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		text := decodeUTF16(data)
+		return formatLines(bufio.NewReader(strings.NewReader(text)), offset, limit, "UTF-16"), nil
+	}
+
+	if bytes.IndexByte(head, 0) >= 0 {
+		return fmt.Sprintf("Binary file (%d bytes, %s) - cannot display as text.", info.Size(), http.DetectContentType(head)), nil
+	}
+
+	return formatLines(reader, offset, limit, ""), nil
+}
+
+// formatLines renders lines [offset, offset+limit) of r with line numbers.
+// Lines that aren't valid UTF-8 are decoded as Latin-1. A limit of 0 means all lines.
+func formatLines(r *bufio.Reader, offset, limit int, encoding string) string {
+	var sb strings.Builder
+	lineNum := 0
+	shown := 0
+	latin1 := false
+
+	for {
+		line, err := r.ReadString('\n')
+		if line == "" && err != nil {
+			break
+		}
+		lineNum++
+
+		if lineNum >= offset && (limit == 0 || shown < limit) {
+			line = strings.TrimRight(line, "\r\n")
+			if lineNum == 1 {
+				line = strings.TrimPrefix(line, "\uFEFF")
+			}
+			if !utf8.ValidString(line) {
+				line = decodeLatin1(line)
+				latin1 = true
+			}
+			if len(line) > maxReadLineLength {
+				line = line[:runeBoundary(line, maxReadLineLength)] + "... (line truncated)"
+			}
+			sb.WriteString(fmt.Sprintf("%6d\t%s\n", lineNum, line))
+			shown++
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if shown == 0 {
+		return fmt.Sprintf("(no lines in range: file has %d lines)", lineNum)
+	}
+
+	last := offset + shown - 1
+	if offset > 1 || last < lineNum {
+		sb.WriteString(fmt.Sprintf("\n[Showing lines %d-%d of %d.", offset, last, lineNum))
+		if last < lineNum {
+			sb.WriteString(fmt.Sprintf(" Use offset=%d to continue.", last+1))
+		}
+		sb.WriteString("]")
+	}
+	if encoding != "" {
+		sb.WriteString(fmt.Sprintf("\n[Note: file is %s encoded; shown decoded]", encoding))
+	}
+	if latin1 {
+		sb.WriteString("\n[Note: file is not valid UTF-8; non-UTF-8 lines were decoded as Latin-1]")
+	}
+
+	return sb.String()
+}
+
+// decodeUTF16 decodes BOM-prefixed UTF-16 data.
+func decodeUTF16(data []byte) string {
+	var order binary.ByteOrder = binary.LittleEndian
+	if data[0] == 0xFE {
+		order = binary.BigEndian
+	}
+	data = data[2:]
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodeLatin1 decodes ISO-8859-1 bytes, which map 1:1 to code points.
+func decodeLatin1(s string) string {
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
+
+// resolvePath joins a relative path onto workspace, so that relative paths
+// name the same file as in the exec tool rather than depending on the
+// process's working directory.
+func resolvePath(workspace, path string) string {
+	if workspace == "" || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workspace, path)
+}
+
+// pathWithin reports whether path lies inside any of the given directories.
+func pathWithin(path string, dirs ...string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		absPath = resolved
+	}
 
-	return string(content), nil
+	for _, dir := range dirs {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(absDir); err == nil {
+			absDir = resolved
+		}
+		rel, err := filepath.Rel(absDir, absPath)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
 }
 
 // WriteFileTool writes content to a file.
@@ -84,6 +288,8 @@ func (t *WriteFileTool) Execute(ctx context.Context, args map[string]interface{}
 		return "", fmt.Errorf("content must be a string")
 	}
 
+	path = resolvePath(t.Workspace, path)
+
 	// Security: ensure path is within workspace
 	if t.Workspace != "" {
 		absPath, err := filepath.Abs(path)
@@ -113,7 +319,9 @@ func (t *WriteFileTool) Execute(ctx context.Context, args map[string]interface{}
 }
 
 // ListDirTool lists directory contents.
-type ListDirTool struct{}
+type ListDirTool struct {
+	Workspace string // Relative paths resolve against this directory (if set)
+}
 
 func (t *ListDirTool) Name() string { return "list_dir" }
 
@@ -139,6 +347,7 @@ func (t *ListDirTool) Execute(ctx context.Context, args map[string]interface{})
 	if !ok {
 		return "", fmt.Errorf("path must be a string")
 	}
+	path = resolvePath(t.Workspace, path)
 
 	entries, err := os.ReadDir(path)
 	if err != nil {
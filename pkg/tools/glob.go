@@ -4,10 +4,12 @@ package tools
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -29,7 +31,8 @@ func (t *GlobTool) Description() string {
 - "**/*.go" - All Go files
 - "src/**/*.ts" - TypeScript files in src
 - "*.md" - Markdown files in current directory
-Returns matching file paths sorted by modification time (newest first).`
+Returns matching file paths sorted by modification time (newest first).
+"**" searches respect .gitignore, .ignore and .domiclawignore files and skip hidden directories.`
 }
 
 func (t *GlobTool) Parameters() map[string]interface{} {
@@ -57,7 +60,7 @@ func (t *GlobTool) Execute(ctx context.Context, args map[string]interface{}) (st
 
 	basePath := t.Workspace
 	if p, ok := args["path"].(string); ok && p != "" {
-		basePath = p
+		basePath = resolvePath(t.Workspace, p)
 	}
 
 	// Handle ** patterns by walking the directory tree
@@ -65,24 +68,19 @@ func (t *GlobTool) Execute(ctx context.Context, args map[string]interface{}) (st
 
 	if strings.Contains(pattern, "**") {
 		// Walk directory tree for ** patterns
-		err := filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil // Skip errors
-			}
-
-			if info.IsDir() {
-				return nil
-			}
-
+		var mu sync.Mutex
+		walker := &Walker{Root: basePath}
+		err := walker.Walk(ctx, func(path string, info fs.FileInfo) error {
 			// Convert ** pattern to check
 			relPath, _ := filepath.Rel(basePath, path)
 			if matchGlobPattern(pattern, relPath) {
+				mu.Lock()
 				matches = append(matches, fileInfo{
 					Path:    path,
 					ModTime: info.ModTime(),
 				})
+				mu.Unlock()
 			}
-
 			return nil
 		})
 
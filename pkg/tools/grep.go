@@ -2,13 +2,16 @@
 package tools
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 )
 
 // GrepTool searches file contents using regular expressions.
@@ -16,15 +19,55 @@ type GrepTool struct {
 	Workspace string
 }
 
+const (
+	// grepDefaultHeadLimit is the number of entries returned when head_limit is not set.
+	grepDefaultHeadLimit = 100
+	// grepMaxCollected bounds memory use on huge trees; the walk stops past it.
+	grepMaxCollected = 10000
+)
+
+// grepFileTypes maps type filter names to file extensions.
+var grepFileTypes = map[string][]string{
+	"go":    {".go"},
+	"ts":    {".ts", ".tsx", ".mts", ".cts"},
+	"js":    {".js", ".jsx", ".mjs", ".cjs"},
+	"py":    {".py", ".pyi"},
+	"rust":  {".rs"},
+	"java":  {".java"},
+	"c":     {".c", ".h"},
+	"cpp":   {".cpp", ".cc", ".cxx", ".hpp", ".hh", ".h"},
+	"cs":    {".cs"},
+	"rb":    {".rb"},
+	"php":   {".php"},
+	"sh":    {".sh", ".bash", ".zsh"},
+	"md":    {".md", ".markdown"},
+	"json":  {".json"},
+	"yaml":  {".yaml", ".yml"},
+	"toml":  {".toml"},
+	"html":  {".html", ".htm"},
+	"css":   {".css", ".scss", ".sass", ".less"},
+	"sql":   {".sql"},
+	"proto": {".proto"},
+}
+
 func (t *GrepTool) Name() string { return "grep" }
 
 func (t *GrepTool) Description() string {
 	return `Search file contents using a regular expression pattern.
-Returns matching lines with file paths and line numbers.
-Supports standard regex syntax (e.g., "log.*Error", "func\s+\w+").`
+Supports standard regex syntax (e.g., "log.*Error", "func\s+\w+").
+Output modes: "content" (matching lines as file:line: text, default), "files_with_matches" (file paths only), "count" (matches per file).
+Use context/context_before/context_after for surrounding lines, type or include to filter files,
+and head_limit with offset to page through large result sets.
+Respects .gitignore, .ignore and .domiclawignore files and skips hidden directories.`
 }
 
 func (t *GrepTool) Parameters() map[string]interface{} {
+	types := make([]string, 0, len(grepFileTypes))
+	for name := range grepFileTypes {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+
 	return map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
@@ -34,23 +77,117 @@ func (t *GrepTool) Parameters() map[string]interface{} {
 			},
 			"path": map[string]interface{}{
 				"type":        "string",
-				"description": "Directory to search in (defaults to workspace)",
+				"description": "File or directory to search in (defaults to workspace)",
 			},
 			"include": map[string]interface{}{
 				"type":        "string",
 				"description": "File pattern to include (e.g., '*.go', '*.{ts,tsx}')",
 			},
+			"type": map[string]interface{}{
+				"type":        "string",
+				"description": "File type to search: " + strings.Join(types, ", "),
+			},
+			"output_mode": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"content", "files_with_matches", "count"},
+				"description": "Output mode (default: content)",
+			},
+			"case_insensitive": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Case-insensitive search",
+			},
+			"multiline": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Let patterns span lines ('.' also matches newlines)",
+			},
+			"context": map[string]interface{}{
+				"type":        "integer",
+				"description": "Lines of context before and after each match (content mode)",
+			},
+			"context_before": map[string]interface{}{
+				"type":        "integer",
+				"description": "Lines of context before each match (content mode)",
+			},
+			"context_after": map[string]interface{}{
+				"type":        "integer",
+				"description": "Lines of context after each match (content mode)",
+			},
+			"head_limit": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Maximum entries (lines, files or counts) to return (default %d)", grepDefaultHeadLimit),
+			},
+			"offset": map[string]interface{}{
+				"type":        "integer",
+				"description": "Skip this many entries before applying head_limit (for paging)",
+			},
 		},
 		"required": []string{"pattern"},
 	}
 }
 
+// grepOptions holds the parsed grep arguments.
+type grepOptions struct {
+	outputMode    string
+	multiline     bool
+	before, after int
+	headLimit     int
+	offset        int
+	include       string
+	extensions    []string
+}
+
 func (t *GrepTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
 	pattern, ok := args["pattern"].(string)
 	if !ok {
 		return "", fmt.Errorf("pattern must be a string")
 	}
 
+	opts := grepOptions{outputMode: "content", headLimit: grepDefaultHeadLimit}
+	if mode, ok := args["output_mode"].(string); ok && mode != "" {
+		if mode != "content" && mode != "files_with_matches" && mode != "count" {
+			return "", fmt.Errorf("invalid output_mode: %s", mode)
+		}
+		opts.outputMode = mode
+	}
+	opts.multiline, _ = args["multiline"].(bool)
+	if c, ok := args["context"].(float64); ok && c > 0 {
+		opts.before, opts.after = int(c), int(c)
+	}
+	if b, ok := args["context_before"].(float64); ok && b > 0 {
+		opts.before = int(b)
+	}
+	if a, ok := args["context_after"].(float64); ok && a > 0 {
+		opts.after = int(a)
+	}
+	if h, ok := args["head_limit"].(float64); ok && h > 0 {
+		opts.headLimit = int(h)
+	}
+	if o, ok := args["offset"].(float64); ok && o > 0 {
+		opts.offset = int(o)
+	}
+	if inc, ok := args["include"].(string); ok {
+		opts.include = inc
+	}
+	if typ, ok := args["type"].(string); ok && typ != "" {
+		exts, ok := grepFileTypes[strings.ToLower(typ)]
+		if !ok {
+			return "", fmt.Errorf("unknown file type: %s", typ)
+		}
+		opts.extensions = exts
+	}
+
+	// Build regex flags
+	flags := ""
+	if ci, _ := args["case_insensitive"].(bool); ci {
+		flags += "i"
+	}
+	if opts.multiline {
+		flags += "s"
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+
 	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return "", fmt.Errorf("invalid regex pattern: %w", err)
@@ -58,115 +195,258 @@ func (t *GrepTool) Execute(ctx context.Context, args map[string]interface{}) (st
 
 	basePath := t.Workspace
 	if p, ok := args["path"].(string); ok && p != "" {
-		basePath = p
-	}
-
-	includePattern := ""
-	if inc, ok := args["include"].(string); ok {
-		includePattern = inc
+		basePath = resolvePath(t.Workspace, p)
 	}
 
-	var results []grepMatch
-	maxResults := 100
+	var (
+		mu        sync.Mutex
+		results   []*grepFileResult
+		collected int
+	)
 
-	err = filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-
-		// Skip directories
-		if info.IsDir() {
-			// Skip hidden directories and common non-code directories
-			if strings.HasPrefix(info.Name(), ".") ||
-				info.Name() == "node_modules" ||
-				info.Name() == "vendor" ||
-				info.Name() == "__pycache__" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Skip binary and large files
+	walker := &Walker{Root: basePath}
+	err = walker.Walk(ctx, func(path string, info fs.FileInfo) error {
+		// Skip large files
 		if info.Size() > 1024*1024 { // Skip files > 1MB
 			return nil
 		}
 
-		// Check include pattern
-		if includePattern != "" && !matchIncludePattern(info.Name(), includePattern) {
+		if !opts.matchesFile(info.Name()) {
 			return nil
 		}
 
 		// Search file
-		matches, err := searchFile(path, re)
-		if err != nil {
-			return nil // Skip files we can't read
+		result, err := searchFile(path, re, opts.multiline)
+		if err != nil || result == nil {
+			return nil // Skip files we can't read and binary files
 		}
 
-		results = append(results, matches...)
-
-		if len(results) >= maxResults {
-			return filepath.SkipAll
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, result)
+		collected += len(result.matched)
+		if collected >= grepMaxCollected {
+			return ErrStopWalk
 		}
-
 		return nil
 	})
-
-	if err != nil && err != filepath.SkipAll {
+	if err != nil {
 		return "", fmt.Errorf("search failed: %w", err)
 	}
 
-	// Format results
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Found %d matches:\n\n", len(results)))
+	// Files are searched concurrently; present results in a stable order
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].file < results[j].file
+	})
 
-	for _, m := range results {
-		sb.WriteString(fmt.Sprintf("%s:%d: %s\n", m.File, m.Line, m.Content))
+	out := formatGrepResults(results, opts)
+	if collected >= grepMaxCollected {
+		out += fmt.Sprintf("\n... (search stopped after %d matching lines; narrow the pattern or path)", grepMaxCollected)
 	}
+	return out, nil
+}
 
-	if len(results) >= maxResults {
-		sb.WriteString("\n... (results truncated)")
+// matchesFile applies the include and type filters to a file name.
+func (o grepOptions) matchesFile(name string) bool {
+	if o.include != "" && !matchIncludePattern(name, o.include) {
+		return false
 	}
-
-	return sb.String(), nil
+	if len(o.extensions) > 0 {
+		ext := strings.ToLower(filepath.Ext(name))
+		for _, e := range o.extensions {
+			if ext == e {
+				return true
+			}
+		}
+		return false
+	}
+	return true
 }
 
-type grepMatch struct {
-	File    string
-	Line    int
-	Content string
+// grepFileResult holds the matches found in a single file.
+type grepFileResult struct {
+	file    string
+	lines   []string // All lines of the file (for context output)
+	matched []int    // 0-based indexes of matching lines, ascending
+	count   int      // Number of matches (differs from len(matched) in multiline mode)
 }
 
-func searchFile(path string, re *regexp.Regexp) ([]grepMatch, error) {
-	file, err := os.Open(path)
+// searchFile searches a file, returning nil if it has no matches or is binary.
+func searchFile(path string, re *regexp.Regexp, multiline bool) (*grepFileResult, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	var matches []grepMatch
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
+	// Skip binary files
+	if bytes.IndexByte(data[:min(len(data), binarySniffLen)], 0) >= 0 {
+		return nil, nil
+	}
 
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
+	content := string(data)
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	result := &grepFileResult{file: path, lines: lines}
+
+	if multiline {
+		// Map each match's byte range onto the lines it spans
+		lineStarts := make([]int, len(lines))
+		pos := 0
+		for i, line := range lines {
+			lineStarts[i] = pos
+			pos += len(line) + 1
+		}
+		seen := make(map[int]bool)
+		for _, loc := range re.FindAllStringIndex(content, -1) {
+			result.count++
+			first := sort.SearchInts(lineStarts, loc[0]+1) - 1
+			last := first
+			if loc[1] > loc[0] {
+				last = sort.SearchInts(lineStarts, loc[1]) - 1
+			}
+			for i := first; i <= last && i < len(lines); i++ {
+				if !seen[i] {
+					seen[i] = true
+					result.matched = append(result.matched, i)
+				}
+			}
+		}
+		sort.Ints(result.matched)
+	} else {
+		for i, line := range lines {
+			if re.MatchString(line) {
+				result.matched = append(result.matched, i)
+			}
+		}
+		result.count = len(result.matched)
+	}
+
+	if len(result.matched) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// formatGrepResults renders results for the requested output mode,
+// applying offset and head_limit to the entries.
+func formatGrepResults(results []*grepFileResult, opts grepOptions) string {
+	var sb strings.Builder
+
+	switch opts.outputMode {
+	case "files_with_matches":
+		start, end := pageBounds(len(results), opts)
+		sb.WriteString(fmt.Sprintf("Found %d files:\n\n", len(results)))
+		for _, r := range results[start:end] {
+			sb.WriteString(r.file + "\n")
+		}
+		writePagingFooter(&sb, start, end, len(results))
+
+	case "count":
+		total := 0
+		for _, r := range results {
+			total += r.count
+		}
+		start, end := pageBounds(len(results), opts)
+		sb.WriteString(fmt.Sprintf("Found %d matches in %d files:\n\n", total, len(results)))
+		for _, r := range results[start:end] {
+			sb.WriteString(fmt.Sprintf("%s:%d\n", r.file, r.count))
+		}
+		writePagingFooter(&sb, start, end, len(results))
 
-		if re.MatchString(line) {
-			// Truncate long lines
-			content := line
-			if len(content) > 200 {
-				content = content[:197] + "..."
+	default:
+		// Entries are matching lines across all files
+		type entry struct {
+			result *grepFileResult
+			line   int
+		}
+		var entries []entry
+		for _, r := range results {
+			for _, line := range r.matched {
+				entries = append(entries, entry{r, line})
+			}
+		}
+
+		start, end := pageBounds(len(entries), opts)
+		sb.WriteString(fmt.Sprintf("Found %d matches:\n\n", len(entries)))
+
+		// Group the selected lines by file so context blocks can be merged
+		var current *grepFileResult
+		var selected []int
+		flush := func() {
+			if current != nil {
+				writeGrepLines(&sb, current, selected, opts.before, opts.after)
+			}
+		}
+		for _, e := range entries[start:end] {
+			if e.result != current {
+				flush()
+				current = e.result
+				selected = nil
 			}
+			selected = append(selected, e.line)
+		}
+		flush()
+		writePagingFooter(&sb, start, end, len(entries))
+	}
+
+	return sb.String()
+}
+
+// writeGrepLines writes the matching lines of a file with optional context.
+// Match lines use "file:line:" and context lines use "file-line-"; "--"
+// separates non-adjacent blocks.
+func writeGrepLines(sb *strings.Builder, r *grepFileResult, matched []int, before, after int) {
+	isMatch := make(map[int]bool, len(matched))
+	for _, i := range matched {
+		isMatch[i] = true
+	}
 
-			matches = append(matches, grepMatch{
-				File:    path,
-				Line:    lineNum,
-				Content: strings.TrimSpace(content),
-			})
+	printed := -1
+	for _, m := range matched {
+		from := max(m-before, printed+1, 0)
+		to := min(m+after, len(r.lines)-1)
+		if (before > 0 || after > 0) && printed >= 0 && from > printed+1 {
+			sb.WriteString("--\n")
+		}
+		for i := from; i <= to; i++ {
+			content := truncateMatchLine(r.lines[i])
+			if isMatch[i] {
+				sb.WriteString(fmt.Sprintf("%s:%d: %s\n", r.file, i+1, content))
+			} else {
+				sb.WriteString(fmt.Sprintf("%s-%d- %s\n", r.file, i+1, content))
+			}
 		}
+		if to > printed {
+			printed = to
+		}
+	}
+}
+
+// truncateMatchLine trims a line and truncates long ones for display.
+func truncateMatchLine(line string) string {
+	content := strings.TrimSpace(line)
+	if len(content) > 200 {
+		content = content[:runeBoundary(content, 197)] + "..."
 	}
+	return content
+}
+
+// pageBounds returns the [start, end) window selected by offset and head_limit.
+func pageBounds(total int, opts grepOptions) (int, int) {
+	start := min(opts.offset, total)
+	end := min(start+opts.headLimit, total)
+	return start, end
+}
 
-	return matches, scanner.Err()
+// writePagingFooter notes when only part of the entries were shown.
+func writePagingFooter(sb *strings.Builder, start, end, total int) {
+	if start == 0 && end == total {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n... (showing %d-%d of %d", start+1, end, total))
+	if end < total {
+		sb.WriteString(fmt.Sprintf("; use offset=%d to see more", end))
+	}
+	sb.WriteString(")")
 }
 
 // matchIncludePattern checks if a filename matches an include pattern.
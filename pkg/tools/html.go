@@ -0,0 +1,625 @@
+package tools
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// htmlNode is an element or text node of a parsed HTML document.
+type htmlNode struct {
+	tag      string // Lower-case element name; "" for text nodes
+	text     string // Raw (still escaped) text for text nodes and raw-text elements
+	attrs    map[string]string
+	parent   *htmlNode
+	children []*htmlNode
+}
+
+// htmlVoidTags never have children.
+var htmlVoidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// htmlRawTextTags hold unparsed text up to their closing tag.
+var htmlRawTextTags = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true, "noscript": true,
+}
+
+// htmlSkipTags are dropped from the converted output as page chrome or non-content.
+var htmlSkipTags = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true,
+	"button": true, "select": true, "textarea": true, "iframe": true, "svg": true,
+	"canvas": true, "dialog": true,
+}
+
+// htmlBlockTags close an open <p>, which HTML allows to be left unclosed.
+var htmlBlockTags = map[string]bool{
+	"p": true, "div": true, "ul": true, "ol": true, "table": true, "pre": true,
+	"blockquote": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "section": true, "article": true, "hr": true, "dl": true,
+}
+
+// parseHTML builds a forgiving document tree. It is not a full HTML5 parser,
+// but handles unclosed tags, comments and raw-text elements well enough to
+// extract readable content.
+func parseHTML(src string) *htmlNode {
+	root := &htmlNode{tag: "#root"}
+	cur := root
+
+	for i := 0; i < len(src); {
+		lt := strings.IndexByte(src[i:], '<')
+		if lt < 0 {
+			cur.appendText(src[i:])
+			break
+		}
+		if lt > 0 {
+			cur.appendText(src[i : i+lt])
+		}
+		i += lt
+		rest := src[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				return root
+			}
+			i += 4 + end + 3
+
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			i += end + 1
+
+		case strings.HasPrefix(rest, "</"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			name := strings.ToLower(strings.TrimSpace(rest[2:end]))
+			if sp := strings.IndexAny(name, " \t\r\n"); sp >= 0 {
+				name = name[:sp]
+			}
+			i += end + 1
+			// Close the nearest open element with that name; stray end tags are ignored
+			for n := cur; n != root; n = n.parent {
+				if n.tag == name {
+					cur = n.parent
+					break
+				}
+			}
+
+		default:
+			tag, attrs, selfClosing, n := parseHTMLTag(rest)
+			if n == 0 {
+				cur.appendText("<")
+				i++
+				continue
+			}
+			i += n
+
+			cur = closeImplied(cur, tag)
+			node := &htmlNode{tag: tag, attrs: attrs, parent: cur}
+			cur.children = append(cur.children, node)
+
+			if htmlRawTextTags[tag] {
+				end := indexEndTag(src[i:], tag)
+				if end < 0 {
+					node.text = src[i:]
+					return root
+				}
+				node.text = src[i : i+end]
+				i += end
+				if gt := strings.IndexByte(src[i:], '>'); gt >= 0 {
+					i += gt + 1
+				} else {
+					i = len(src)
+				}
+				continue
+			}
+			if !htmlVoidTags[tag] && !selfClosing {
+				cur = node
+			}
+		}
+	}
+
+	return root
+}
+
+// appendText adds a text node, merging with a preceding text node.
+func (n *htmlNode) appendText(text string) {
+	if k := len(n.children); k > 0 && n.children[k-1].tag == "" {
+		n.children[k-1].text += text
+		return
+	}
+	n.children = append(n.children, &htmlNode{text: text, parent: n})
+}
+
+// closeImplied closes elements that an opening tag implicitly ends,
+// such as a previous <li> or an open <p>.
+func closeImplied(cur *htmlNode, tag string) *htmlNode {
+	closeTo := func(names []string, stop []string) *htmlNode {
+		for n := cur; n.parent != nil; n = n.parent {
+			for _, s := range stop {
+				if n.tag == s {
+					return cur
+				}
+			}
+			for _, name := range names {
+				if n.tag == name {
+					return n.parent
+				}
+			}
+		}
+		return cur
+	}
+
+	switch {
+	case tag == "li":
+		return closeTo([]string{"li"}, []string{"ul", "ol"})
+	case tag == "dt" || tag == "dd":
+		return closeTo([]string{"dt", "dd"}, []string{"dl"})
+	case tag == "tr":
+		return closeTo([]string{"tr"}, []string{"table"})
+	case tag == "td" || tag == "th":
+		return closeTo([]string{"td", "th"}, []string{"tr", "table"})
+	case htmlBlockTags[tag]:
+		return closeTo([]string{"p"}, []string{"div", "li", "td", "th", "blockquote", "section", "article", "main", "body"})
+	}
+	return cur
+}
+
+// parseHTMLTag parses an opening tag at the start of s. It returns the number
+// of bytes consumed, or 0 if s does not start with a valid tag.
+func parseHTMLTag(s string) (tag string, attrs map[string]string, selfClosing bool, n int) {
+	if len(s) < 2 || !isASCIILetter(s[1]) {
+		return "", nil, false, 0
+	}
+
+	i := 1
+	for i < len(s) && isTagNameChar(s[i]) {
+		i++
+	}
+	tag = strings.ToLower(s[1:i])
+
+	for i < len(s) {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		switch {
+		case s[i] == '>':
+			return tag, attrs, selfClosing, i + 1
+		case s[i] == '/':
+			selfClosing = true
+			i++
+			continue
+		}
+		selfClosing = false
+
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(s[start:i])
+		if name == "" {
+			i++
+			continue
+		}
+
+		value := ""
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				end := strings.IndexByte(s[i+1:], quote)
+				if end < 0 {
+					return "", nil, false, 0
+				}
+				value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[start:i]
+			}
+		}
+
+		if attrs == nil {
+			attrs = make(map[string]string)
+		}
+		attrs[name] = html.UnescapeString(value)
+	}
+
+	return "", nil, false, 0
+}
+
+// indexEndTag finds the closing tag for a raw-text element, case-insensitively.
+func indexEndTag(s, tag string) int {
+	for i := 0; ; {
+		j := strings.Index(s[i:], "</")
+		if j < 0 {
+			return -1
+		}
+		i += j
+		end := i + 2 + len(tag)
+		if end <= len(s) && strings.EqualFold(s[i+2:end], tag) &&
+			(end == len(s) || s[end] == '>' || isHTMLSpace(s[end])) {
+			return i
+		}
+		i += 2
+	}
+}
+
+func isASCIILetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+func isTagNameChar(c byte) bool {
+	return isASCIILetter(c) || (c >= '0' && c <= '9') || c == '-' || c == ':'
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// find returns the first element (depth-first) for which match is true.
+func (n *htmlNode) find(match func(*htmlNode) bool) *htmlNode {
+	for _, c := range n.children {
+		if c.tag == "" {
+			continue
+		}
+		if match(c) {
+			return c
+		}
+		if found := c.find(match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// textContent returns the unescaped text of n and its descendants.
+func (n *htmlNode) textContent() string {
+	if n.tag == "" || htmlRawTextTags[n.tag] {
+		return html.UnescapeString(n.text)
+	}
+	var sb strings.Builder
+	for _, c := range n.children {
+		sb.WriteString(c.textContent())
+	}
+	return sb.String()
+}
+
+// htmlTitle returns the document's <title>, if any.
+func htmlTitle(doc *htmlNode) string {
+	title := doc.find(func(n *htmlNode) bool { return n.tag == "title" })
+	if title == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(title.textContent()), " ")
+}
+
+// mainContent picks the element most likely to hold the page's content:
+// <main>, then role="main", then <article>, then <body>.
+func mainContent(doc *htmlNode) *htmlNode {
+	for _, match := range []func(*htmlNode) bool{
+		func(n *htmlNode) bool { return n.tag == "main" },
+		func(n *htmlNode) bool { return n.attrs["role"] == "main" },
+		func(n *htmlNode) bool { return n.tag == "article" },
+		func(n *htmlNode) bool { return n.tag == "body" },
+	} {
+		if n := doc.find(match); n != nil {
+			return n
+		}
+	}
+	return doc
+}
+
+// htmlToMarkdown converts the main content of an HTML document to markdown.
+// Relative links are resolved against base.
+func htmlToMarkdown(doc *htmlNode, base *url.URL) string {
+	r := &markdownRenderer{base: base}
+	return normalizeMarkdown(r.children(mainContent(doc)))
+}
+
+// markdownRenderer renders an htmlNode tree as markdown.
+type markdownRenderer struct {
+	base *url.URL
+}
+
+func (r *markdownRenderer) children(n *htmlNode) string {
+	var sb strings.Builder
+	for _, c := range n.children {
+		r.node(&sb, c)
+	}
+	return sb.String()
+}
+
+func (r *markdownRenderer) node(sb *strings.Builder, n *htmlNode) {
+	if n.tag == "" {
+		text := collapseSpace(html.UnescapeString(n.text))
+		// Don't start lines with the space left over from indentation
+		if out := sb.String(); out == "" || strings.HasSuffix(out, "\n") {
+			text = strings.TrimLeft(text, " ")
+		}
+		sb.WriteString(text)
+		return
+	}
+	if _, hidden := n.attrs["hidden"]; hidden || htmlSkipTags[n.tag] || n.attrs["aria-hidden"] == "true" {
+		return
+	}
+
+	switch n.tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if text := oneLine(r.children(n)); text != "" {
+			level := int(n.tag[1] - '0')
+			sb.WriteString("\n\n" + strings.Repeat("#", level) + " " + text + "\n\n")
+		}
+
+	case "br":
+		sb.WriteString("\n")
+
+	case "hr":
+		sb.WriteString("\n\n---\n\n")
+
+	case "strong", "b":
+		r.wrap(sb, n, "**")
+
+	case "em", "i":
+		r.wrap(sb, n, "*")
+
+	case "del", "s", "strike":
+		r.wrap(sb, n, "~~")
+
+	case "code", "kbd", "samp":
+		if text := strings.TrimSpace(n.textContent()); text != "" {
+			sb.WriteString("`" + text + "`")
+		}
+
+	case "pre":
+		code := strings.Trim(n.textContent(), "\n")
+		if strings.TrimSpace(code) != "" {
+			sb.WriteString("\n\n```" + codeLanguage(n) + "\n" + code + "\n```\n\n")
+		}
+
+	case "a":
+		text := oneLine(r.children(n))
+		href := strings.TrimSpace(n.attrs["href"])
+		switch {
+		case text == "":
+		case href == "" || strings.HasPrefix(href, "javascript:") || strings.HasPrefix(href, "#"):
+			sb.WriteString(text)
+		default:
+			sb.WriteString("[" + text + "](" + r.resolve(href) + ")")
+		}
+
+	case "img":
+		if src := r.resolve(n.attrs["src"]); src != "" && !strings.HasPrefix(src, "data:") {
+			sb.WriteString("![" + oneLine(n.attrs["alt"]) + "](" + src + ")")
+		}
+
+	case "ul", "ol":
+		r.list(sb, n)
+
+	case "li":
+		// A list item outside of a list
+		sb.WriteString("\n- " + strings.TrimSpace(normalizeMarkdown(r.children(n))) + "\n")
+
+	case "blockquote":
+		quoted := normalizeMarkdown(r.children(n))
+		if quoted != "" {
+			sb.WriteString("\n\n" + prefixLines(quoted, "> ", "> ") + "\n\n")
+		}
+
+	case "table":
+		r.table(sb, n)
+
+	case "dt":
+		sb.WriteString("\n\n**" + oneLine(r.children(n)) + "**\n")
+
+	case "dd":
+		sb.WriteString("\n: " + oneLine(r.children(n)) + "\n")
+
+	case "p", "div", "section", "article", "main", "body", "figure", "figcaption",
+		"dl", "address", "details", "summary", "center", "caption":
+		sb.WriteString("\n\n" + r.children(n) + "\n\n")
+
+	default:
+		sb.WriteString(r.children(n))
+	}
+}
+
+// wrap renders n's children surrounded by marker, e.g. **bold**.
+func (r *markdownRenderer) wrap(sb *strings.Builder, n *htmlNode, marker string) {
+	text := r.children(n)
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		sb.WriteString(text)
+		return
+	}
+	// Keep surrounding spaces outside the markers so they still render
+	if strings.HasPrefix(text, " ") {
+		sb.WriteString(" ")
+	}
+	sb.WriteString(marker + trimmed + marker)
+	if strings.HasSuffix(text, " ") {
+		sb.WriteString(" ")
+	}
+}
+
+// list renders a <ul> or <ol>, indenting nested content under each item.
+func (r *markdownRenderer) list(sb *strings.Builder, n *htmlNode) {
+	var items []string
+	num := 1
+	for _, c := range n.children {
+		if c.tag != "li" {
+			continue
+		}
+		marker := "- "
+		if n.tag == "ol" {
+			marker = fmt.Sprintf("%d. ", num)
+			num++
+		}
+		content := normalizeMarkdown(r.children(c))
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+	if len(items) > 0 {
+		sb.WriteString("\n\n" + strings.Join(items, "\n") + "\n\n")
+	}
+}
+
+// table renders a <table> as a markdown table, treating the first row as the header.
+func (r *markdownRenderer) table(sb *strings.Builder, n *htmlNode) {
+	var rows [][]string
+	var collect func(*htmlNode)
+	collect = func(n *htmlNode) {
+		for _, c := range n.children {
+			switch c.tag {
+			case "tr":
+				var cells []string
+				for _, cell := range c.children {
+					if cell.tag == "td" || cell.tag == "th" {
+						cells = append(cells, strings.ReplaceAll(oneLine(r.children(cell)), "|", `\|`))
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			case "thead", "tbody", "tfoot":
+				collect(c)
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return
+	}
+
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+
+	sb.WriteString("\n\n")
+	for i, row := range rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			sb.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// resolve makes a link absolute relative to the page URL.
+func (r *markdownRenderer) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || r.base == nil {
+		return ref
+	}
+	u, err := r.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// codeLanguage extracts a language hint from class="language-x" on a <pre> or its <code>.
+func codeLanguage(pre *htmlNode) string {
+	classes := pre.attrs["class"]
+	if code := pre.find(func(n *htmlNode) bool { return n.tag == "code" }); code != nil {
+		classes += " " + code.attrs["class"]
+	}
+	for _, class := range strings.Fields(classes) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(class, prefix) {
+				return strings.TrimPrefix(class, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+// collapseSpace replaces runs of whitespace with a single space.
+func collapseSpace(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				sb.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// oneLine flattens text to a single line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// prefixLines prefixes the first line with first and the remaining lines with rest.
+func prefixLines(s, first, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line != "":
+			lines[i] = rest + line
+		default:
+			lines[i] = strings.TrimRight(rest, " ")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizeMarkdown trims trailing spaces and collapses runs of blank lines,
+// leaving fenced code blocks untouched.
+func normalizeMarkdown(s string) string {
+	var out []string
+	inFence := false
+	blank := false
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		} else if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			blank = true
+			continue
+		}
+		if blank && len(out) > 0 {
+			out = append(out, "")
+		}
+		blank = false
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
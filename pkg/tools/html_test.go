@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	base, _ := url.Parse("https://example.com/docs/page.html")
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "paragraphs and emphasis",
+			html: "<p>Hello <b>bold</b> and <em>italic</em> and <del>gone</del></p><p>Second</p>",
+			want: "Hello **bold** and *italic* and ~~gone~~\n\nSecond",
+		},
+		{
+			name: "unclosed paragraphs",
+			html: "<p>one<p>two",
+			want: "one\n\ntwo",
+		},
+		{
+			name: "headings",
+			html: "<h1>Title</h1><p>text</p><h3> Sub  <i>part</i></h3>",
+			want: "# Title\n\ntext\n\n### Sub *part*",
+		},
+		{
+			name: "whitespace collapses",
+			html: "<p>\n    lots   of\n\tspace\n</p>",
+			want: "lots of space",
+		},
+		{
+			name: "entities",
+			html: "<p>&lt;tag&gt; &amp; &quot;quoted&quot; &copy;</p>",
+			want: "<tag> & \"quoted\" ©",
+		},
+		{
+			name: "relative links resolve against the page",
+			html: `<p><a href="/api">API</a>, <a href="other.html">other</a>, <a href="https://go.dev/">Go</a></p>`,
+			want: "[API](https://example.com/api), [other](https://example.com/docs/other.html), [Go](https://go.dev/)",
+		},
+		{
+			name: "fragment and script links keep their text",
+			html: `<p><a href="#top">Top</a> <a href="javascript:void(0)">Click</a></p>`,
+			want: "Top Click",
+		},
+		{
+			name: "images",
+			html: `<p><img src="logo.png" alt="The logo"><img src="data:image/png;base64,xx" alt="inline"></p>`,
+			want: "![The logo](https://example.com/docs/logo.png)",
+		},
+		{
+			name: "unordered list",
+			html: "<ul><li>one</li><li>two</li></ul>",
+			want: "- one\n- two",
+		},
+		{
+			name: "ordered list",
+			html: "<ol><li>first<li>second</ol>",
+			want: "1. first\n2. second",
+		},
+		{
+			name: "nested list",
+			html: "<ul><li>outer<ul><li>inner</li></ul></li><li>next</li></ul>",
+			want: "- outer\n\n  - inner\n- next",
+		},
+		{
+			name: "code block with language",
+			html: "<pre><code class=\"language-go\">if x &lt; 1 {\n\treturn\n}\n</code></pre>",
+			want: "```go\nif x < 1 {\n\treturn\n}\n```",
+		},
+		{
+			name: "inline code",
+			html: "<p>Run <code>go test</code> now</p>",
+			want: "Run `go test` now",
+		},
+		{
+			name: "blockquote",
+			html: "<blockquote><p>quoted</p><p>more</p></blockquote>",
+			want: "> quoted\n>\n> more",
+		},
+		{
+			name: "table",
+			html: "<table><thead><tr><th>Name</th><th>Value</th></tr></thead><tbody><tr><td>a|b</td><td>1</td></tr><tr><td>c</td></tr></tbody></table>",
+			want: "| Name | Value |\n| --- | --- |\n| a\\|b | 1 |\n| c |  |",
+		},
+		{
+			name: "page chrome is dropped",
+			html: "<body><nav>Menu</nav><header>Site</header><p>Content</p><script>alert(1)</script><style>p{}</style><footer>Footer</footer></body>",
+			want: "Content",
+		},
+		{
+			name: "main content is preferred",
+			html: "<body><div>Sidebar</div><main><p>Article body</p></main></body>",
+			want: "Article body",
+		},
+		{
+			name: "article when there is no main",
+			html: "<body><div>Teaser</div><article><h2>Post</h2><p>Text</p></article></body>",
+			want: "## Post\n\nText",
+		},
+		{
+			name: "hidden elements and comments",
+			html: `<p>shown<!-- a comment --></p><p hidden>secret</p><div aria-hidden="true">icon</div>`,
+			want: "shown",
+		},
+		{
+			name: "script containing tags",
+			html: "<p>before</p><script>document.write('<p>injected</p>')</script><p>after</p>",
+			want: "before\n\nafter",
+		},
+		{
+			name: "line breaks and rules",
+			html: "<p>one<br>two</p><hr><p>three</p>",
+			want: "one\ntwo\n\n---\n\nthree",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToMarkdown(parseHTML(tt.html), base); got != tt.want {
+				t.Errorf("htmlToMarkdown(%q)\n got: %q\nwant: %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLTitle(t *testing.T) {
+	tests := []struct {
+		html string
+		want string
+	}{
+		{"<html><head><title>  My\n  Page </title></head></html>", "My Page"},
+		{"<title>A &amp; B</title>", "A & B"},
+		{"<p>no title</p>", ""},
+	}
+	for _, tt := range tests {
+		if got := htmlTitle(parseHTML(tt.html)); got != tt.want {
+			t.Errorf("htmlTitle(%q) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
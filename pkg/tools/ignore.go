@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileNames are read in every directory the walker visits.
+// Later files take precedence over earlier ones, as with ripgrep.
+var ignoreFileNames = []string{".gitignore", ".ignore", ".domiclawignore"}
+
+// ignoreRule is a single line of an ignore file.
+type ignoreRule struct {
+	base     string   // Directory of the ignore file, slash-separated and relative to the repository root ("" = root)
+	parts    []string // Pattern split on "/"
+	negate   bool     // "!pattern" re-includes a path
+	dirOnly  bool     // "pattern/" only matches directories
+	anchored bool     // Pattern contains a slash, so it is relative to base
+}
+
+// ignoreRules is the ordered set of rules in effect for a directory.
+// The last matching rule wins.
+type ignoreRules []ignoreRule
+
+// loadIgnoreFile parses an ignore file located in directory base (relative to
+// the repository root, or to the walk root outside a repository).
+func loadIgnoreFile(file, base string) ignoreRules {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules ignoreRules
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:] // Escaped leading "#" or "!"
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		rule.parts = strings.Split(line, "/")
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// with returns rules extended by the ignore files found in dir.
+func (r ignoreRules) with(dir, rel string) ignoreRules {
+	extended := r
+	for _, name := range ignoreFileNames {
+		if more := loadIgnoreFile(filepath.Join(dir, name), rel); len(more) > 0 {
+			// Copy so sibling directories don't share the appended slice
+			extended = append(append(ignoreRules{}, extended...), more...)
+		}
+	}
+	return extended
+}
+
+// ignored reports whether rel (slash-separated, relative to the same root as
+// the rules' bases) is ignored.
+func (r ignoreRules) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range r {
+		if rule.matches(rel, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (rule ignoreRule) matches(rel string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+
+	// Rules only apply below the directory holding the ignore file
+	if rule.base != "" {
+		if !strings.HasPrefix(rel, rule.base+"/") {
+			return false
+		}
+		rel = rel[len(rule.base)+1:]
+	}
+
+	if !rule.anchored {
+		// Unanchored patterns match the name at any depth
+		matched, _ := path.Match(rule.parts[0], path.Base(rel))
+		return matched
+	}
+	return matchParts(rule.parts, strings.Split(rel, "/"))
+}
@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeFiles creates files under dir; names are slash-separated.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIgnoreRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  string // .gitignore content
+		base   string // Directory of the ignore file
+		path   string
+		isDir  bool
+		ignore bool
+	}{
+		{"name pattern", "*.log", "", "app.log", false, true},
+		{"name pattern at depth", "*.log", "", "a/b/app.log", false, true},
+		{"name pattern miss", "*.log", "", "app.go", false, false},
+		{"bare name matches directory", "node_modules", "", "web/node_modules", true, true},
+
+		{"leading slash anchors", "/build", "", "build", true, true},
+		{"leading slash not at depth", "/build", "", "src/build", true, false},
+		{"inner slash anchors", "docs/*.md", "", "docs/a.md", false, true},
+		{"inner slash not at depth", "docs/*.md", "", "x/docs/a.md", false, false},
+		{"star stays in one segment", "docs/*.md", "", "docs/sub/a.md", false, false},
+		{"double star prefix", "**/cache", "", "a/b/cache", true, true},
+		{"double star prefix at root", "**/cache", "", "cache", true, true},
+		{"double star inside", "a/**/z.txt", "", "a/b/c/z.txt", false, true},
+
+		{"trailing slash matches directory", "tmp/", "", "tmp", true, true},
+		{"trailing slash skips file", "tmp/", "", "tmp", false, false},
+
+		{"negation re-includes", "*.log\n!keep.log", "", "keep.log", false, false},
+		{"negation leaves others", "*.log\n!keep.log", "", "other.log", false, true},
+		{"last match wins", "!keep.log\n*.log", "", "keep.log", false, true},
+
+		{"nested file applies below", "/gen", "sub", "sub/gen", true, true},
+		{"nested file anchors to its dir", "/gen", "sub", "gen", true, false},
+		{"nested file anchors below its dir", "/gen", "sub", "sub/x/gen", true, false},
+		{"nested name pattern", "*.out", "sub", "sub/x/a.out", false, true},
+		{"nested name pattern outside", "*.out", "sub", "other/a.out", false, false},
+
+		{"comments and blanks", "# *.go\n\n", "", "main.go", false, false},
+		{"escaped hash", `\#notes`, "", "#notes", false, true},
+		{"escaped bang", `\!important`, "", "!important", false, true},
+		{"trailing spaces trimmed", "*.log   ", "", "app.log", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), ".gitignore")
+			if err := os.WriteFile(file, []byte(tt.rules), 0644); err != nil {
+				t.Fatal(err)
+			}
+			rules := loadIgnoreFile(file, tt.base)
+			if got := rules.ignored(tt.path, tt.isDir); got != tt.ignore {
+				t.Errorf("rules %q in %q: ignored(%q, dir=%v) = %v, want %v",
+					tt.rules, tt.base, tt.path, tt.isDir, got, tt.ignore)
+			}
+		})
+	}
+}
+
+func TestWalkerIgnoreFilesFromRepoRoot(t *testing.T) {
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		".git/info/exclude":  "secret.txt\n",
+		".gitignore":         "*.tmp\n/top.txt\nsub/deep/*.gen\n",
+		"top.txt":            "",
+		"sub/.gitignore":     "local.txt\n",
+		"sub/a.go":           "",
+		"sub/x.tmp":          "",
+		"sub/top.txt":        "",
+		"sub/secret.txt":     "",
+		"sub/local.txt":      "",
+		"sub/deep/z.gen":     "",
+		"sub/deep/keep.go":   "",
+		"other/local.txt":    "",
+		"sub/deep/.ignore":   "!z.gen\nkeep.go\n",
+		"sub/deep/inner.tmp": "",
+	})
+
+	tests := []struct {
+		name string
+		root string
+		want []string // Relative to repo
+	}{
+		{
+			name: "repo root",
+			root: repo,
+			want: []string{"other/local.txt", "sub/a.go", "sub/deep/z.gen", "sub/top.txt"},
+		},
+		{
+			name: "subdirectory",
+			root: filepath.Join(repo, "sub"),
+			want: []string{"sub/a.go", "sub/deep/z.gen", "sub/top.txt"},
+		},
+		{
+			name: "nested subdirectory",
+			root: filepath.Join(repo, "sub", "deep"),
+			want: []string{"sub/deep/z.gen"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu  sync.Mutex
+				got []string
+			)
+			w := &Walker{Root: tt.root}
+			err := w.Walk(context.Background(), func(path string, info fs.FileInfo) error {
+				rel, _ := filepath.Rel(repo, path)
+				mu.Lock()
+				got = append(got, filepath.ToSlash(rel))
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("walked %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
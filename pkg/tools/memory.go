@@ -0,0 +1,342 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DomiYoung/domiclaw/pkg/memory"
+)
+
+// maxRecallResults caps the matches returned by recall.
+const maxRecallResults = 50
+
+// memoryScopeParam is the "scope" parameter shared by the memory tools.
+func memoryScopeParam(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{"global", "project"},
+		"description": description,
+	}
+}
+
+// scopedStore returns the store selected by the "scope" argument, which
+// defaults to global, and a label for messages.
+func scopedStore(args map[string]interface{}, global, project *memory.Store) (*memory.Store, string, error) {
+	scope, _ := args["scope"].(string)
+	switch scope {
+	case "", "global":
+		return global, "long-term memory", nil
+	case "project":
+		if project == nil {
+			return nil, "", fmt.Errorf("no project memory: the working directory is not inside a git repository")
+		}
+		rel, err := filepath.Rel(project.Root(), project.LongTermPath())
+		if err != nil {
+			rel = project.LongTermPath()
+		}
+		return project, fmt.Sprintf("project memory (%s)", filepath.ToSlash(rel)), nil
+	default:
+		return nil, "", fmt.Errorf("scope must be \"global\" or \"project\"")
+	}
+}
+
+// searchScopes returns the stores to search for the "scope" argument; with
+// no scope both are searched, project first.
+func searchScopes(args map[string]interface{}, global, project *memory.Store) ([]*memory.Store, error) {
+	if scope, _ := args["scope"].(string); scope != "" {
+		store, _, err := scopedStore(args, global, project)
+		if err != nil {
+			return nil, err
+		}
+		return []*memory.Store{store}, nil
+	}
+	if project != nil {
+		return []*memory.Store{project, global}, nil
+	}
+	return []*memory.Store{global}, nil
+}
+
+// RememberTool saves a durable fact to long-term memory (MEMORY.md).
+type RememberTool struct {
+	Store   *memory.Store
+	Project *memory.Store // Memory of the current repository; nil outside one
+}
+
+func (t *RememberTool) Name() string { return "remember" }
+
+func (t *RememberTool) Description() string {
+	return `Save a durable fact to long-term memory (MEMORY.md) so it is available in future sessions.
+Use for user preferences, project conventions, decisions and lessons learned - not for transient progress (use "note").
+Each fact is stored as one bullet under a section; duplicates are ignored.
+Use scope "project" for facts that only apply to the current repository.`
+}
+
+func (t *RememberTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "The fact to remember, as a single concise sentence",
+			},
+			"section": map[string]interface{}{
+				"type":        "string",
+				"description": fmt.Sprintf("Section of MEMORY.md, e.g. \"Preferences\" or \"Important Information\" (default %q)", memory.DefaultSection),
+			},
+			"scope": memoryScopeParam("\"global\" (default) for facts that hold everywhere, \"project\" for facts about the current repository"),
+		},
+		"required": []string{"content"},
+	}
+}
+
+func (t *RememberTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	content, ok := args["content"].(string)
+	if !ok || strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("content must be a non-empty string")
+	}
+	section, _ := args["section"].(string)
+	if strings.TrimSpace(section) == "" {
+		section = memory.DefaultSection
+	}
+
+	store, label, err := scopedStore(args, t.Store, t.Project)
+	if err != nil {
+		return "", err
+	}
+
+	added, err := store.AppendLongTermSection(section, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to update memory: %w", err)
+	}
+	if !added {
+		return fmt.Sprintf("Already in %s; nothing changed.", label), nil
+	}
+	return fmt.Sprintf("Remembered under %q in %s.", section, label), nil
+}
+
+// NoteTool appends an entry to today's daily note.
+type NoteTool struct {
+	Store   *memory.Store
+	Project *memory.Store
+}
+
+func (t *NoteTool) Name() string { return "note" }
+
+func (t *NoteTool) Description() string {
+	return `Append a timestamped entry to today's daily log. Use for progress, findings and context worth keeping for the next few days.`
+}
+
+func (t *NoteTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "The note to record (markdown)",
+			},
+			"scope": memoryScopeParam("\"global\" (default) or \"project\" for the current repository's daily notes"),
+		},
+		"required": []string{"content"},
+	}
+}
+
+func (t *NoteTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	content, ok := args["content"].(string)
+	if !ok || strings.TrimSpace(content) == "" {
+		return "", fmt.Errorf("content must be a non-empty string")
+	}
+
+	store, _, err := scopedStore(args, t.Store, t.Project)
+	if err != nil {
+		return "", err
+	}
+
+	entry := fmt.Sprintf("- [%s] %s\n", time.Now().Format("15:04"), strings.TrimSpace(content))
+	if err := store.AppendToday(entry); err != nil {
+		return "", fmt.Errorf("failed to write daily note: %w", err)
+	}
+	return "Added to today's daily note.", nil
+}
+
+// RecallTool searches long-term memory and daily notes.
+type RecallTool struct {
+	Store   *memory.Store
+	Project *memory.Store
+}
+
+func (t *RecallTool) Name() string { return "recall" }
+
+func (t *RecallTool) Description() string {
+	return `Search long-term memory and all daily notes for lines containing every word of the query (case-insensitive).`
+}
+
+func (t *RecallTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Words to look for",
+			},
+			"scope": memoryScopeParam("Search only \"global\" or only \"project\" memory (default both)"),
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *RecallTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query must be a non-empty string")
+	}
+
+	stores, err := searchScopes(args, t.Store, t.Project)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []memory.Match
+	for _, store := range stores {
+		matches = append(matches, store.Search(query)...)
+	}
+	if len(matches) == 0 {
+		return fmt.Sprintf("Nothing in memory matches %q.", query), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d matches:\n\n", len(matches)))
+	for i, m := range matches {
+		if i >= maxRecallResults {
+			sb.WriteString(fmt.Sprintf("\n... (%d more; refine the query)", len(matches)-maxRecallResults))
+			break
+		}
+		location := fmt.Sprintf("%s:%d", m.Source, m.Line)
+		if m.Section != "" {
+			location += fmt.Sprintf(" [%s]", m.Section)
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s\n", location, m.Text))
+	}
+	return sb.String(), nil
+}
+
+// ForgetTool removes entries from long-term memory.
+type ForgetTool struct {
+	Store   *memory.Store
+	Project *memory.Store
+}
+
+func (t *ForgetTool) Name() string { return "forget" }
+
+func (t *ForgetTool) Description() string {
+	return `Remove an outdated or wrong entry from long-term memory (MEMORY.md).
+Matches entries containing the given text; if several match, nothing is removed unless "all" is true.`
+}
+
+func (t *ForgetTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"entry": map[string]interface{}{
+				"type":        "string",
+				"description": "Text identifying the entry to remove (use recall to find it)",
+			},
+			"all": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Remove every matching entry (default false)",
+			},
+			"scope": memoryScopeParam("\"global\" (default) or \"project\" for the current repository's memory"),
+		},
+		"required": []string{"entry"},
+	}
+}
+
+func (t *ForgetTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	entry, ok := args["entry"].(string)
+	if !ok {
+		return "", fmt.Errorf("entry must be a string")
+	}
+	all, _ := args["all"].(bool)
+
+	store, label, err := scopedStore(args, t.Store, t.Project)
+	if err != nil {
+		return "", err
+	}
+
+	removed, err := store.Forget(entry, all)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Removed %d entries from %s:\n%s", len(removed), label, strings.Join(removed, "\n")), nil
+}
+
+// MemorySearchTool ranks memory sections, daily notes and saved sessions by
+// relevance to a query.
+type MemorySearchTool struct {
+	Store       *memory.Store
+	Project     *memory.Store
+	SessionsDir string
+}
+
+func (t *MemorySearchTool) Name() string { return "memory_search" }
+
+func (t *MemorySearchTool) Description() string {
+	return `Full-text search (BM25) over long-term memory sections, all daily notes and saved session transcripts.
+Returns the most relevant snippets first. Use to recall past decisions, fixes and context from earlier sessions.`
+}
+
+func (t *MemorySearchTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "What to look for (keywords or a short question)",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Maximum results (default 10)",
+			},
+			"scope": memoryScopeParam("Search only \"global\" or only \"project\" memory (default both)"),
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *MemorySearchTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query must be a non-empty string")
+	}
+	limit := 10
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	stores, err := searchScopes(args, t.Store, t.Project)
+	if err != nil {
+		return "", err
+	}
+	sessionsDir := t.SessionsDir
+	if scope, _ := args["scope"].(string); scope == "project" {
+		sessionsDir = ""
+	}
+
+	hits := memory.BuildIndex(sessionsDir, stores...).Search(query, limit)
+	return FormatMemoryHits(query, hits), nil
+}
+
+// FormatMemoryHits formats memory search results for display.
+func FormatMemoryHits(query string, hits []memory.Hit) string {
+	if len(hits) == 0 {
+		return fmt.Sprintf("Nothing in memory matches %q.", query)
+	}
+
+	var sb strings.Builder
+	for i, h := range hits {
+		sb.WriteString(fmt.Sprintf("%d. [%s] (score %.2f)\n   %s\n\n", i+1, h.Location(), h.Score, h.Snippet))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
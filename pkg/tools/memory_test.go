@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DomiYoung/domiclaw/pkg/memory"
+)
+
+func TestMemoryToolsOfferScope(t *testing.T) {
+	for _, tool := range []Tool{&RememberTool{}, &NoteTool{}, &RecallTool{}, &ForgetTool{}, &MemorySearchTool{}} {
+		props, _ := tool.Parameters()["properties"].(map[string]interface{})
+		if _, ok := props["scope"]; !ok {
+			t.Errorf("%s does not declare a scope parameter", tool.Name())
+		}
+	}
+}
+
+func TestRememberScope(t *testing.T) {
+	tests := []struct {
+		name       string
+		scope      string
+		inRepo     bool
+		wantFile   string // Relative to the workspace or the repository
+		wantGlobal bool
+		wantErr    string
+	}{
+		{name: "default is global", inRepo: true, wantFile: "MEMORY.md", wantGlobal: true},
+		{name: "global", scope: "global", inRepo: true, wantFile: "MEMORY.md", wantGlobal: true},
+		{name: "project", scope: "project", inRepo: true, wantFile: ".domiclaw/MEMORY.md"},
+		{name: "project outside a repository", scope: "project", wantErr: "no project memory"},
+		{name: "unknown scope", scope: "team", inRepo: true, wantErr: "scope must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace, repo := t.TempDir(), t.TempDir()
+			tool := &RememberTool{Store: memory.NewStore(workspace)}
+			if tt.inRepo {
+				if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+					t.Fatal(err)
+				}
+				tool.Project = memory.NewProjectStore(memory.FindProjectRoot(repo))
+			}
+
+			args := map[string]interface{}{"content": "Tests run with go test -race"}
+			if tt.scope != "" {
+				args["scope"] = tt.scope
+			}
+			_, err := tool.Execute(context.Background(), args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			written, other := filepath.Join(repo, tt.wantFile), filepath.Join(workspace, "MEMORY.md")
+			if tt.wantGlobal {
+				written, other = filepath.Join(workspace, tt.wantFile), filepath.Join(repo, ".domiclaw", "MEMORY.md")
+			}
+			data, err := os.ReadFile(written)
+			if err != nil || !strings.Contains(string(data), "go test -race") {
+				t.Errorf("fact not written to %s: %q, %v", written, data, err)
+			}
+			if data, err := os.ReadFile(other); err == nil && strings.Contains(string(data), "go test -race") {
+				t.Errorf("fact also written to %s", other)
+			}
+		})
+	}
+}
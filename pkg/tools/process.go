@@ -0,0 +1,366 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxProcessOutput caps the buffered output per background process.
+// Older output is dropped once the cap is reached.
+const maxProcessOutput = 1024 * 1024
+
+// ProcessManager tracks background processes started by the exec tool.
+type ProcessManager struct {
+	mu     sync.Mutex
+	procs  map[string]*BackgroundProcess
+	nextID int
+}
+
+// BackgroundProcess is a long-running command such as a dev server or watcher.
+type BackgroundProcess struct {
+	ID      string
+	Command string
+	Workdir string
+	Started time.Time
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	mu       sync.Mutex
+	output   []byte
+	readPos  int // Offset in output up to which the model has read
+	lost     int // Unread bytes dropped because of maxProcessOutput
+	done     bool
+	exitCode int
+}
+
+// NewProcessManager creates a new process manager.
+func NewProcessManager() *ProcessManager {
+	return &ProcessManager{
+		procs: make(map[string]*BackgroundProcess),
+	}
+}
+
+// Start launches command in the background and returns immediately.
+func (m *ProcessManager) Start(sandbox *Sandbox, workdir, command string) (*BackgroundProcess, error) {
+	cmd, err := sandbox.Command(context.Background(), workdir, "sh", "-c", command)
+	if err != nil {
+		return nil, err
+	}
+	setProcessGroup(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin: %w", err)
+	}
+
+	m.mu.Lock()
+	m.nextID++
+	p := &BackgroundProcess{
+		ID:      fmt.Sprintf("bg-%d", m.nextID),
+		Command: command,
+		Workdir: workdir,
+		Started: time.Now(),
+		cmd:     cmd,
+		stdin:   stdin,
+	}
+	m.mu.Unlock()
+
+	// stdout and stderr are interleaved in arrival order
+	cmd.Stdout = p
+	cmd.Stderr = p
+
+	if err := cmd.Start(); err != nil {
+		stdin.Close()
+		return nil, fmt.Errorf("failed to start process: %w", err)
+	}
+
+	m.mu.Lock()
+	m.procs[p.ID] = p
+	m.mu.Unlock()
+
+	go func() {
+		cmd.Wait()
+		p.mu.Lock()
+		p.done = true
+		p.exitCode = cmd.ProcessState.ExitCode()
+		p.mu.Unlock()
+	}()
+
+	return p, nil
+}
+
+// Get returns a background process by ID.
+func (m *ProcessManager) Get(id string) (*BackgroundProcess, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.procs[id]
+	if !ok {
+		return nil, fmt.Errorf("no background process with id %q", id)
+	}
+	return p, nil
+}
+
+// List returns all background processes ordered by start time.
+func (m *ProcessManager) List() []*BackgroundProcess {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	procs := make([]*BackgroundProcess, 0, len(m.procs))
+	for _, p := range m.procs {
+		procs = append(procs, p)
+	}
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].Started.Before(procs[j].Started)
+	})
+	return procs
+}
+
+// KillAll kills every running background process.
+func (m *ProcessManager) KillAll() {
+	for _, p := range m.List() {
+		p.Kill()
+	}
+}
+
+// Write appends process output to the buffer. It implements io.Writer.
+func (p *BackgroundProcess) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.output = append(p.output, b...)
+	if over := len(p.output) - maxProcessOutput; over > 0 {
+		p.output = p.output[over:]
+		p.readPos -= over
+		if p.readPos < 0 {
+			p.lost -= p.readPos
+			p.readPos = 0
+		}
+	}
+	return len(b), nil
+}
+
+// ReadNew returns output produced since the previous call.
+func (p *BackgroundProcess) ReadNew() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := string(p.output[p.readPos:])
+	p.readPos = len(p.output)
+	if p.lost > 0 {
+		out = fmt.Sprintf("[... %d bytes of older output dropped ...]\n", p.lost) + out
+		p.lost = 0
+	}
+	return out
+}
+
+// Status returns "running" or "exited (code N)".
+func (p *BackgroundProcess) Status() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.done {
+		return "running"
+	}
+	return fmt.Sprintf("exited (code %d)", p.exitCode)
+}
+
+// Running reports whether the process is still running.
+func (p *BackgroundProcess) Running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.done
+}
+
+// SendInput writes input to the process's stdin.
+func (p *BackgroundProcess) SendInput(input string) error {
+	if !p.Running() {
+		return fmt.Errorf("process %s has exited", p.ID)
+	}
+	_, err := io.WriteString(p.stdin, input)
+	return err
+}
+
+// Kill terminates the process and all of its children.
+func (p *BackgroundProcess) Kill() {
+	if !p.Running() {
+		return
+	}
+	p.stdin.Close()
+	killProcessGroup(p.cmd)
+}
+
+// ProcessOutputTool reads new output from a background process.
+type ProcessOutputTool struct {
+	Manager *ProcessManager
+}
+
+func (t *ProcessOutputTool) Name() string { return "process_output" }
+
+func (t *ProcessOutputTool) Description() string {
+	return "Read output from a background process (started with exec background=true) produced since the last check."
+}
+
+func (t *ProcessOutputTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "The background process id (e.g. 'bg-1')",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *ProcessOutputTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, ok := args["id"].(string)
+	if !ok {
+		return "", fmt.Errorf("id must be a string")
+	}
+
+	p, err := t.Manager.Get(id)
+	if err != nil {
+		return "", err
+	}
+
+	out := p.ReadNew()
+	if out == "" {
+		out = "(no new output)\n"
+	} else if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return fmt.Sprintf("%s[%s: %s]", out, p.ID, p.Status()), nil
+}
+
+// ProcessInputTool sends input to a background process.
+type ProcessInputTool struct {
+	Manager *ProcessManager
+}
+
+func (t *ProcessInputTool) Name() string { return "process_input" }
+
+func (t *ProcessInputTool) Description() string {
+	return "Send text to the stdin of a background process. Include a trailing newline to submit a line."
+}
+
+func (t *ProcessInputTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "The background process id (e.g. 'bg-1')",
+			},
+			"input": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to write to stdin",
+			},
+		},
+		"required": []string{"id", "input"},
+	}
+}
+
+func (t *ProcessInputTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, ok := args["id"].(string)
+	if !ok {
+		return "", fmt.Errorf("id must be a string")
+	}
+
+	input, ok := args["input"].(string)
+	if !ok {
+		return "", fmt.Errorf("input must be a string")
+	}
+
+	p, err := t.Manager.Get(id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.SendInput(input); err != nil {
+		return "", fmt.Errorf("failed to send input: %w", err)
+	}
+	return fmt.Sprintf("Sent %d bytes to %s", len(input), p.ID), nil
+}
+
+// ProcessListTool lists background processes.
+type ProcessListTool struct {
+	Manager *ProcessManager
+}
+
+func (t *ProcessListTool) Name() string { return "process_list" }
+
+func (t *ProcessListTool) Description() string {
+	return "List background processes with their status and command."
+}
+
+func (t *ProcessListTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+func (t *ProcessListTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	procs := t.Manager.List()
+	if len(procs) == 0 {
+		return "No background processes.", nil
+	}
+
+	var sb strings.Builder
+	for _, p := range procs {
+		sb.WriteString(fmt.Sprintf("%s  %-16s  started %s  %s\n",
+			p.ID, p.Status(), p.Started.Format("15:04:05"), p.Command))
+	}
+	return sb.String(), nil
+}
+
+// ProcessKillTool kills a background process.
+type ProcessKillTool struct {
+	Manager *ProcessManager
+}
+
+func (t *ProcessKillTool) Name() string { return "process_kill" }
+
+func (t *ProcessKillTool) Description() string {
+	return "Kill a background process and all of its children."
+}
+
+func (t *ProcessKillTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "The background process id (e.g. 'bg-1')",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *ProcessKillTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	id, ok := args["id"].(string)
+	if !ok {
+		return "", fmt.Errorf("id must be a string")
+	}
+
+	p, err := t.Manager.Get(id)
+	if err != nil {
+		return "", err
+	}
+
+	if !p.Running() {
+		return fmt.Sprintf("%s already %s", p.ID, p.Status()), nil
+	}
+	p.Kill()
+	return fmt.Sprintf("Killed %s", p.ID), nil
+}
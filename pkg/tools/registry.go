@@ -27,6 +27,7 @@ type Tool interface {
 type Registry struct {
 	tools   map[string]Tool
 	aliases map[string]string // alias -> canonical name
+	limiter *OutputLimiter    // Optional; bounds results sent to the model
 	mu      sync.RWMutex
 }
 
@@ -54,6 +55,22 @@ func (r *Registry) RegisterAlias(alias, canonical string) {
 	r.aliases[strings.ToLower(alias)] = canonical
 }
 
+// SetOutputLimiter sets the limiter applied to successful tool results.
+func (r *Registry) SetOutputLimiter(limiter *OutputLimiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limiter = limiter
+}
+
+// LimitOutput applies the registry's output limit for tool to output, for
+// command output the agent produces outside a tool call.
+func (r *Registry) LimitOutput(tool, output string) string {
+	r.mu.RLock()
+	limiter := r.limiter
+	r.mu.RUnlock()
+	return limiter.Apply(tool, output)
+}
+
 // resolveAlias resolves a tool name through aliases (case-insensitive).
 func (r *Registry) resolveAlias(name string) string {
 	if canonical, ok := r.aliases[strings.ToLower(name)]; ok {
@@ -91,6 +108,29 @@ func (r *Registry) List() []string {
 	return names
 }
 
+// Subset returns a registry holding only the named tools, with the same
+// output limiter. Aliases are kept for the tools that remain. Unknown names
+// are ignored.
+func (r *Registry) Subset(names ...string) *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sub := NewRegistry()
+	sub.limiter = r.limiter
+	for _, name := range names {
+		resolved := r.resolveAlias(name)
+		if tool, ok := r.tools[resolved]; ok {
+			sub.tools[resolved] = tool
+		}
+	}
+	for alias, canonical := range r.aliases {
+		if _, ok := sub.tools[canonical]; ok {
+			sub.aliases[alias] = canonical
+		}
+	}
+	return sub
+}
+
 // GetDefinitions returns tool definitions for the LLM.
 func (r *Registry) GetDefinitions() []map[string]interface{} {
 	r.mu.RLock()
@@ -115,6 +155,7 @@ func (r *Registry) Execute(ctx context.Context, name string, args map[string]int
 	r.mu.RLock()
 	resolved := r.resolveAlias(name)
 	tool, ok := r.tools[resolved]
+	limiter := r.limiter
 	r.mu.RUnlock()
 
 	if !ok {
@@ -124,7 +165,11 @@ func (r *Registry) Execute(ctx context.Context, name string, args map[string]int
 		}
 	}
 
-	return tool.Execute(ctx, args)
+	result, err := tool.Execute(ctx, args)
+	if err != nil || limiter.exempt(resolved, args) {
+		return result, err
+	}
+	return limiter.Apply(resolved, result), nil
 }
 
 // ToolNotFoundError is returned when a tool is not found.
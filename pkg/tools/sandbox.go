@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// sandboxCheckTimeout bounds the probe run by Check.
+const sandboxCheckTimeout = 10 * time.Second
+
+// Sandbox confines commands started by the exec tool.
+// The host filesystem is mounted read-only, only the working directory and
+// WritablePaths are writable, /tmp is private and the network is disabled
+// unless AllowNetwork is set.
+//
+// A nil *Sandbox runs commands directly on the host.
+type Sandbox struct {
+	Backend       string // "auto", "bwrap" or "unshare"
+	AllowNetwork  bool
+	WritablePaths []string
+}
+
+// Command builds an exec.Cmd that runs argv inside the sandbox with workdir
+// as its working directory.
+func (s *Sandbox) Command(ctx context.Context, workdir string, argv ...string) (*exec.Cmd, error) {
+	if s == nil {
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		cmd.Dir = workdir
+		return cmd, nil
+	}
+	return s.command(ctx, workdir, argv)
+}
+
+// Describe returns a short human-readable summary of the sandbox policy.
+func (s *Sandbox) Describe() string {
+	if s == nil {
+		return "none"
+	}
+	network := "no network"
+	if s.AllowNetwork {
+		network = "network allowed"
+	}
+	return "read-only system, writable working directory, private /tmp, " + network
+}
+
+// Check runs a trivial command in the sandbox to verify that it works on
+// this host, e.g. that bwrap is installed and unprivileged user namespaces
+// are allowed, so a broken setup is reported once instead of on every
+// command.
+func (s *Sandbox) Check(workdir string) error {
+	if s == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sandboxCheckTimeout)
+	defer cancel()
+
+	cmd, err := s.Command(ctx, workdir, "true")
+	if err != nil {
+		return err
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
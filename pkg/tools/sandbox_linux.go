@@ -0,0 +1,110 @@
+//go:build linux
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// unsharePrelude runs inside fresh user/mount namespaces created by unshare(1).
+// It remounts every inherited mount read-only, re-binds the writable paths
+// read-write, gives the command a private /tmp and then execs the command.
+//
+// Arguments: <workdir> <writable>... -- <argv>...
+const unsharePrelude = `set -e
+wd="$1"; shift
+for m in $(awk '{print $5}' /proc/self/mountinfo); do
+	mount -o remount,bind,ro "$m" 2>/dev/null || true
+done
+case "$wd" in
+	/tmp|/tmp/*) ;;
+	*) mount -t tmpfs tmpfs /tmp ;;
+esac
+mount --bind "$wd" "$wd"
+mount -o remount,bind,rw "$wd"
+while [ "$1" != "--" ]; do
+	mount --bind "$1" "$1"
+	mount -o remount,bind,rw "$1"
+	shift
+done
+shift
+cd "$wd"
+exec "$@"`
+
+func (s *Sandbox) command(ctx context.Context, workdir string, argv []string) (*exec.Cmd, error) {
+	absWorkdir, err := filepath.Abs(workdir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workdir: %w", err)
+	}
+
+	var writable []string
+	for _, p := range s.WritablePaths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve writable path %q: %w", p, err)
+		}
+		writable = append(writable, abs)
+	}
+
+	backend, err := s.resolveBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	switch backend {
+	case "bwrap":
+		args = []string{"bwrap",
+			"--ro-bind", "/", "/",
+			"--dev", "/dev",
+			"--proc", "/proc",
+			"--tmpfs", "/tmp",
+			"--bind", absWorkdir, absWorkdir,
+		}
+		for _, p := range writable {
+			args = append(args, "--bind", p, p)
+		}
+		args = append(args, "--unshare-pid", "--die-with-parent")
+		if !s.AllowNetwork {
+			args = append(args, "--unshare-net")
+		}
+		args = append(args, "--chdir", absWorkdir, "--")
+	case "unshare":
+		args = []string{"unshare", "--user", "--map-root-user", "--mount", "--pid", "--fork", "--mount-proc"}
+		if !s.AllowNetwork {
+			args = append(args, "--net")
+		}
+		args = append(args, "sh", "-c", unsharePrelude, "sandbox", absWorkdir)
+		args = append(args, writable...)
+		args = append(args, "--")
+	}
+	args = append(args, argv...)
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = absWorkdir
+	return cmd, nil
+}
+
+// resolveBackend picks the sandbox backend, preferring bubblewrap.
+func (s *Sandbox) resolveBackend() (string, error) {
+	switch s.Backend {
+	case "", "auto":
+		if _, err := exec.LookPath("bwrap"); err == nil {
+			return "bwrap", nil
+		}
+		if _, err := exec.LookPath("unshare"); err == nil {
+			return "unshare", nil
+		}
+		return "", fmt.Errorf("sandbox unavailable: install bubblewrap (bwrap) or util-linux unshare, or disable the sandbox profile")
+	case "bwrap", "unshare":
+		if _, err := exec.LookPath(s.Backend); err != nil {
+			return "", fmt.Errorf("sandbox backend %q not found in PATH", s.Backend)
+		}
+		return s.Backend, nil
+	default:
+		return "", fmt.Errorf("unknown sandbox backend: %s", s.Backend)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+func (s *Sandbox) command(ctx context.Context, workdir string, argv []string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("sandbox is not supported on %s; disable the sandbox profile to run commands unconfined", runtime.GOOS)
+}
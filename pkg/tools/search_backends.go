@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// searchHTTPClient is shared by backends that don't set their own client.
+var searchHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// BraveBackend searches with the Brave Search API.
+type BraveBackend struct {
+	APIKey string
+	Client *http.Client
+}
+
+func (b *BraveBackend) Name() string { return "brave" }
+
+func (b *BraveBackend) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	apiURL := fmt.Sprintf("https://api.search.brave.com/res/v1/web/search?q=%s&count=%d",
+		url.QueryEscape(query), maxResults)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", b.APIKey)
+
+	var result braveSearchResponse
+	if err := doSearchRequest(b.Client, req, &result); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(result.Web.Results))
+	for _, r := range result.Web.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Description})
+	}
+	return results, nil
+}
+
+type braveSearchResponse struct {
+	Web struct {
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+		} `json:"results"`
+	} `json:"web"`
+}
+
+// TavilyBackend searches with the Tavily API.
+type TavilyBackend struct {
+	APIKey string
+	Client *http.Client
+}
+
+func (b *TavilyBackend) Name() string { return "tavily" }
+
+func (b *TavilyBackend) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	apiURL := "https://api.tavily.com/search"
+
+	reqBody := map[string]interface{}{
+		"api_key":     b.APIKey,
+		"query":       query,
+		"max_results": maxResults,
+	}
+
+	reqData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(string(reqData)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	var result tavilySearchResponse
+	if err := doSearchRequest(b.Client, req, &result); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(result.Results))
+	for _, r := range result.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
+
+type tavilySearchResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+// SearXNGBackend searches a SearXNG instance, e.g. one self-hosted on the
+// local network. The instance must have the JSON output format enabled.
+type SearXNGBackend struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func (b *SearXNGBackend) Name() string { return "searxng" }
+
+func (b *SearXNGBackend) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	apiURL := fmt.Sprintf("%s/search?q=%s&format=json",
+		strings.TrimRight(b.BaseURL, "/"), url.QueryEscape(query))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	var result searxngSearchResponse
+	if err := doSearchRequest(b.Client, req, &result); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(result.Results))
+	for _, r := range result.Results {
+		if maxResults > 0 && len(results) >= maxResults {
+			break
+		}
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
+
+type searxngSearchResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+// doSearchRequest sends req and decodes a JSON response into out.
+func doSearchRequest(client *http.Client, req *http.Request, out interface{}) error {
+	if client == nil {
+		client = searchHTTPClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("search request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("search failed: %s - %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
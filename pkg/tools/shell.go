@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShellSession is a long-lived bash process shared by all exec calls of an
+// agent session, so cd, exported variables, virtualenv activation and shell
+// functions carry over between commands.
+//
+// Each command is followed by a sentinel line carrying its exit code and the
+// shell's working directory, which marks where its output ends.
+type ShellSession struct {
+	Dir string // Initial working directory
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stdout   *bufio.Reader
+	stderr   *bufio.Reader
+	sentinel string
+	cwd      string
+}
+
+// ShellResult holds the outcome of a single command.
+type ShellResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Cwd      string
+}
+
+// NewShellSession creates a shell session that starts in dir.
+// The bash process is started lazily on the first command.
+func NewShellSession(dir string) *ShellSession {
+	return &ShellSession{Dir: dir, cwd: dir}
+}
+
+// Available reports whether bash can be found in PATH.
+func (s *ShellSession) Available() bool {
+	_, err := exec.LookPath("bash")
+	return err == nil
+}
+
+// Cwd returns the shell's current working directory as of the last command.
+func (s *ShellSession) Cwd() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cwd
+}
+
+// Run executes command in the session and waits for it to finish.
+// If the command exceeds timeout the shell is killed and the next call
+// starts a fresh session.
+func (s *ShellSession) Run(ctx context.Context, sandbox *Sandbox, command string, timeout time.Duration) (*ShellResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cmd == nil {
+		if err := s.start(sandbox); err != nil {
+			return nil, err
+		}
+	}
+
+	// eval keeps syntax errors from desynchronizing the shell, and stdin is
+	// detached so commands can't swallow the sentinel lines that follow.
+	script := fmt.Sprintf("eval %s < /dev/null\n__dc_ec=$?\nprintf '\\n%s %%d %%s\\n' \"$__dc_ec\" \"$PWD\"\nprintf '\\n%s\\n' >&2\n",
+		shellQuote(command), s.sentinel, s.sentinel)
+	if _, err := io.WriteString(s.stdin, script); err != nil {
+		s.kill()
+		return nil, fmt.Errorf("shell session is not writable: %w", err)
+	}
+
+	stdoutCh := make(chan streamResult, 1)
+	stderrCh := make(chan streamResult, 1)
+	go readUntilSentinel(s.stdout, s.sentinel, stdoutCh)
+	go readUntilSentinel(s.stderr, s.sentinel, stderrCh)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var out, errOut streamResult
+	var gotOut, gotErr bool
+	for !gotOut || !gotErr {
+		select {
+		case out = <-stdoutCh:
+			gotOut = true
+		case errOut = <-stderrCh:
+			gotErr = true
+		case <-timer.C:
+			s.kill()
+			return s.partial(out, errOut, stdoutCh, stderrCh), fmt.Errorf("command timed out after %v (shell session was reset)", timeout)
+		case <-ctx.Done():
+			s.kill()
+			return s.partial(out, errOut, stdoutCh, stderrCh), ctx.Err()
+		}
+		// Stop selecting on a stream once it has delivered
+		if gotOut {
+			stdoutCh = nil
+		}
+		if gotErr {
+			stderrCh = nil
+		}
+	}
+
+	result := &ShellResult{
+		Stdout: out.output,
+		Stderr: errOut.output,
+		Cwd:    s.cwd,
+	}
+
+	// The shell exited (e.g. the command ran "exit"); start over next time
+	if out.err != nil || errOut.err != nil {
+		s.kill()
+		result.ExitCode = -1
+		return result, fmt.Errorf("shell session exited (it will be restarted on the next command)")
+	}
+
+	// Sentinel line format: "<sentinel> <exit code> <cwd>"
+	fields := strings.SplitN(strings.TrimSpace(out.marker), " ", 3)
+	if len(fields) >= 2 {
+		result.ExitCode, _ = strconv.Atoi(fields[1])
+	}
+	if len(fields) == 3 {
+		s.cwd = fields[2]
+		result.Cwd = s.cwd
+	}
+
+	return result, nil
+}
+
+// Reset kills the shell; the next command starts a fresh session in Dir.
+func (s *ShellSession) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kill()
+	s.cwd = s.Dir
+}
+
+// Close terminates the shell process.
+func (s *ShellSession) Close() {
+	s.Reset()
+}
+
+// start launches the bash process. Caller must hold s.mu.
+func (s *ShellSession) start(sandbox *Sandbox) error {
+	cmd, err := sandbox.Command(context.Background(), s.cwd, "bash", "--noprofile", "--norc")
+	if err != nil {
+		return err
+	}
+	setProcessGroup(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open shell stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open shell stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open shell stderr: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start shell: %w", err)
+	}
+
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+
+	s.cmd = cmd
+	s.stdin = stdin
+	s.stdout = bufio.NewReader(stdout)
+	s.stderr = bufio.NewReader(stderr)
+	s.sentinel = "__DOMICLAW_" + hex.EncodeToString(nonce) + "__"
+	return nil
+}
+
+// kill terminates the shell and its children. Caller must hold s.mu.
+func (s *ShellSession) kill() {
+	if s.cmd == nil {
+		return
+	}
+	s.stdin.Close()
+	killProcessGroup(s.cmd)
+	s.cmd.Wait()
+	s.cmd = nil
+}
+
+// partial collects whatever output was read before a command was aborted.
+// Streams that already finished are passed as nil channels.
+func (s *ShellSession) partial(out, errOut streamResult, stdoutCh, stderrCh <-chan streamResult) *ShellResult {
+	result := &ShellResult{Stdout: out.output, Stderr: errOut.output, ExitCode: -1, Cwd: s.cwd}
+	grace := time.After(2 * time.Second)
+	for stdoutCh != nil || stderrCh != nil {
+		select {
+		case r := <-stdoutCh:
+			result.Stdout = r.output
+			stdoutCh = nil
+		case r := <-stderrCh:
+			result.Stderr = r.output
+			stderrCh = nil
+		case <-grace:
+			return result
+		}
+	}
+	return result
+}
+
+type streamResult struct {
+	output string // Everything before the sentinel line
+	marker string // The sentinel line itself
+	err    error  // Set if the stream ended before the sentinel
+}
+
+// readUntilSentinel reads r up to and including the next sentinel line.
+func readUntilSentinel(r *bufio.Reader, sentinel string, ch chan<- streamResult) {
+	var sb strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if strings.HasPrefix(line, sentinel) {
+			// Drop the newline printed in front of the sentinel
+			ch <- streamResult{output: strings.TrimSuffix(sb.String(), "\n"), marker: line}
+			return
+		}
+		sb.WriteString(line)
+		if err != nil {
+			ch <- streamResult{output: sb.String(), err: err}
+			return
+		}
+	}
+}
+
+// shellQuote quotes s for safe use as a single shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Todo item statuses.
+const (
+	TodoPending    = "pending"
+	TodoInProgress = "in_progress"
+	TodoDone       = "done"
+)
+
+// TodoItem is one step of the agent's plan.
+type TodoItem struct {
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+// TodoList is the agent's plan for the current session.
+type TodoList struct {
+	mu    sync.Mutex
+	items []TodoItem
+}
+
+// NewTodoList creates an empty todo list.
+func NewTodoList() *TodoList {
+	return &TodoList{}
+}
+
+// Items returns a copy of the items.
+func (t *TodoList) Items() []TodoItem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TodoItem(nil), t.items...)
+}
+
+// Set replaces the items, e.g. when restoring a session.
+func (t *TodoList) Set(items []TodoItem) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.items = append([]TodoItem(nil), items...)
+}
+
+// Open returns the items that are not done.
+func (t *TodoList) Open() []TodoItem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var open []TodoItem
+	for _, item := range t.items {
+		if item.Status != TodoDone {
+			open = append(open, item)
+		}
+	}
+	return open
+}
+
+// Render formats the list as one line per item, e.g. "[x] Write tests".
+// It returns "" for an empty list.
+func (t *TodoList) Render() string {
+	return RenderTodos(t.Items())
+}
+
+// RenderTodos formats items as one line per item.
+func RenderTodos(items []TodoItem) string {
+	var sb strings.Builder
+	for _, item := range items {
+		mark := " "
+		switch item.Status {
+		case TodoDone:
+			mark = "x"
+		case TodoInProgress:
+			mark = "~"
+		}
+		fmt.Fprintf(&sb, "[%s] %s\n", mark, item.Content)
+	}
+	return sb.String()
+}
+
+// Progress returns e.g. "2/5 done".
+func (t *TodoList) Progress() string {
+	items := t.Items()
+	done := 0
+	for _, item := range items {
+		if item.Status == TodoDone {
+			done++
+		}
+	}
+	return fmt.Sprintf("%d/%d done", done, len(items))
+}
+
+// TodoTool lets the agent write and update its todo list.
+type TodoTool struct {
+	List *TodoList
+}
+
+func (t *TodoTool) Name() string { return "todo" }
+
+func (t *TodoTool) Description() string {
+	return "Write or update your todo list for multi-step work. Pass the complete list every time; it replaces the previous one. " +
+		"Create it when you start a task with several steps, keep exactly one item in_progress while you work, and mark items done as soon as they are finished. " +
+		"The current list is shown to you every turn, and a task cannot be completed while items are still open."
+}
+
+func (t *TodoTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"todos": map[string]interface{}{
+				"type":        "array",
+				"description": "The full todo list, in order",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"content": map[string]interface{}{
+							"type":        "string",
+							"description": "What needs to be done",
+						},
+						"status": map[string]interface{}{
+							"type": "string",
+							"enum": []string{TodoPending, TodoInProgress, TodoDone},
+						},
+					},
+					"required": []string{"content", "status"},
+				},
+			},
+		},
+		"required": []string{"todos"},
+	}
+}
+
+func (t *TodoTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	raw, ok := args["todos"].([]interface{})
+	if !ok {
+		return "", fmt.Errorf("todos must be an array")
+	}
+
+	items := make([]TodoItem, 0, len(raw))
+	for i, r := range raw {
+		obj, ok := r.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("todos[%d] must be an object with content and status", i)
+		}
+		content, _ := obj["content"].(string)
+		content = strings.TrimSpace(content)
+		if content == "" {
+			return "", fmt.Errorf("todos[%d] has no content", i)
+		}
+		status, _ := obj["status"].(string)
+		switch strings.ToLower(strings.TrimSpace(status)) {
+		case "", TodoPending:
+			status = TodoPending
+		case TodoInProgress, "in-progress", "in progress":
+			status = TodoInProgress
+		case TodoDone, "completed", "complete":
+			status = TodoDone
+		default:
+			return "", fmt.Errorf("todos[%d] has invalid status %q (use pending, in_progress or done)", i, status)
+		}
+		items = append(items, TodoItem{Content: content, Status: status})
+	}
+
+	t.List.Set(items)
+	if len(items) == 0 {
+		return "Todo list cleared.", nil
+	}
+	return fmt.Sprintf("Todo list updated (%s):\n%s", t.List.Progress(), t.List.Render()), nil
+}
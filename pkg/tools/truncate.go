@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// spillRetention is how long spill files are kept before being pruned.
+const spillRetention = 24 * time.Hour
+
+// OutputLimit bounds a single tool result. Zero fields mean no limit.
+type OutputLimit struct {
+	MaxLines int
+	MaxBytes int
+}
+
+// OutputLimiter truncates large tool results, keeping the head and tail and
+// saving the full output to a spill file the model can page through.
+type OutputLimiter struct {
+	SpillDir string
+	Default  OutputLimit
+	PerTool  map[string]OutputLimit
+
+	mu     sync.Mutex
+	seq    int
+	pruned bool
+}
+
+// limitFor returns the limit that applies to a tool.
+func (o *OutputLimiter) limitFor(tool string) OutputLimit {
+	if limit, ok := o.PerTool[tool]; ok {
+		return limit
+	}
+	return o.Default
+}
+
+// exempt reports whether a call's result is passed through unlimited:
+// read_file paging through a spill file, which would otherwise be truncated
+// and spilled again.
+func (o *OutputLimiter) exempt(tool string, args map[string]interface{}) bool {
+	if o == nil || o.SpillDir == "" || tool != "read_file" {
+		return false
+	}
+	path, _ := args["path"].(string)
+	return filepath.IsAbs(path) && pathWithin(path, o.SpillDir)
+}
+
+// Apply returns output unchanged if it fits the tool's limit. Otherwise it
+// returns the first and last parts of the output with an omission marker,
+// plus a pointer to the spill file holding the full output.
+func (o *OutputLimiter) Apply(tool, output string) string {
+	if o == nil {
+		return output
+	}
+
+	limit := o.limitFor(tool)
+	lines := strings.SplitAfter(output, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	overLines := limit.MaxLines > 0 && len(lines) > limit.MaxLines
+	overBytes := limit.MaxBytes > 0 && len(output) > limit.MaxBytes
+	if !overLines && !overBytes {
+		return output
+	}
+
+	truncated := output
+	if overLines {
+		// Keep more of the tail: errors and summaries usually come last
+		head := limit.MaxLines * 2 / 5
+		tail := limit.MaxLines - head
+		omitted := len(lines) - head - tail
+		truncated = strings.Join(lines[:head], "") +
+			fmt.Sprintf("\n... [%d lines omitted] ...\n\n", omitted) +
+			strings.Join(lines[len(lines)-tail:], "")
+	}
+	if limit.MaxBytes > 0 && len(truncated) > limit.MaxBytes {
+		head := runeBoundary(truncated, limit.MaxBytes*2/5)
+		tail := runeBoundary(truncated, len(truncated)-(limit.MaxBytes-head))
+		truncated = truncated[:head] +
+			fmt.Sprintf("\n... [%d bytes omitted] ...\n", tail-head) +
+			truncated[tail:]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(truncated)
+	if !strings.HasSuffix(truncated, "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fmt.Sprintf("\n[Output truncated: %d lines, %d bytes total.", len(lines), len(output)))
+	if path, err := o.spill(tool, output); err == nil {
+		sb.WriteString(fmt.Sprintf(" Full output saved to %s - use read_file with offset/limit to page through it.", path))
+	}
+	sb.WriteString("]")
+
+	return sb.String()
+}
+
+// spill writes the full output to a new file in SpillDir.
+func (o *OutputLimiter) spill(tool, output string) (string, error) {
+	if o.SpillDir == "" {
+		return "", fmt.Errorf("no spill directory configured")
+	}
+
+	o.mu.Lock()
+	o.seq++
+	seq := o.seq
+	prune := !o.pruned
+	o.pruned = true
+	o.mu.Unlock()
+
+	if err := os.MkdirAll(o.SpillDir, 0755); err != nil {
+		return "", err
+	}
+	if prune {
+		o.pruneSpillFiles()
+	}
+
+	name := fmt.Sprintf("%s-%s-%d.txt", tool, time.Now().Format("20060102-150405"), seq)
+	path := filepath.Join(o.SpillDir, name)
+	if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// pruneSpillFiles removes spill files older than spillRetention.
+func (o *OutputLimiter) pruneSpillFiles() {
+	entries, err := os.ReadDir(o.SpillDir)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-spillRetention)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		if info.ModTime().Before(cutoff) {
+			os.Remove(filepath.Join(o.SpillDir, entry.Name()))
+		}
+	}
+}
+
+// runeBoundary moves i back to the start of the UTF-8 sequence containing it.
+func runeBoundary(s string, i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if i >= len(s) {
+		return len(s)
+	}
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// staticTool returns a fixed output.
+type staticTool struct {
+	name   string
+	output string
+}
+
+func (t *staticTool) Name() string                       { return t.name }
+func (t *staticTool) Description() string                { return "Returns a fixed output" }
+func (t *staticTool) Parameters() map[string]interface{} { return map[string]interface{}{} }
+func (t *staticTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	return t.output, nil
+}
+
+func TestOutputLimiterApply(t *testing.T) {
+	numbered := func(n int) string {
+		var sb strings.Builder
+		for i := 1; i <= n; i++ {
+			fmt.Fprintf(&sb, "line %d\n", i)
+		}
+		return sb.String()
+	}
+
+	tests := []struct {
+		name    string
+		limit   OutputLimit
+		output  string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:   "fits",
+			limit:  OutputLimit{MaxLines: 10, MaxBytes: 1000},
+			output: numbered(10),
+			want:   []string{numbered(10)},
+		},
+		{
+			name:    "too many lines keeps head and tail",
+			limit:   OutputLimit{MaxLines: 10},
+			output:  numbered(100),
+			want:    []string{"line 1\n", "line 4\n", "... [90 lines omitted] ...", "line 95\n", "line 100\n", "[Output truncated: 100 lines, 792 bytes total."},
+			notWant: []string{"line 5\n", "line 94\n"},
+		},
+		{
+			name:    "too many bytes",
+			limit:   OutputLimit{MaxBytes: 100},
+			output:  strings.Repeat("x", 1000),
+			want:    []string{strings.Repeat("x", 40) + "\n... [900 bytes omitted] ...\n" + strings.Repeat("x", 60)},
+			notWant: []string{strings.Repeat("x", 61)},
+		},
+		{
+			name:    "byte cut keeps whole runes",
+			limit:   OutputLimit{MaxBytes: 10},
+			output:  strings.Repeat("é", 20),
+			want:    []string{strings.Repeat("é", 2) + "\n... ["},
+			notWant: []string{"\uFFFD"},
+		},
+		{
+			name:   "zero limit is unlimited",
+			output: numbered(1000),
+			want:   []string{numbered(1000)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &OutputLimiter{SpillDir: t.TempDir(), Default: tt.limit}
+			got := limiter.Apply("exec", tt.output)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("output missing %q:\n%s", w, got)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("output contains %q:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
+
+// TestSpillFileReadBack checks that the model can page through a spill file
+// with read_file without the pages being truncated and spilled again.
+func TestSpillFileReadBack(t *testing.T) {
+	workspace, spillDir := t.TempDir(), t.TempDir()
+
+	var full strings.Builder
+	for i := 1; i <= 2000; i++ {
+		fmt.Fprintf(&full, "%04d %s\n", i, strings.Repeat("data ", 30))
+	}
+
+	registry := NewRegistry()
+	registry.Register(&staticTool{name: "exec", output: full.String()})
+	registry.Register(&ReadFileTool{Workspace: workspace, AllowedDirs: []string{spillDir}, DefaultLimit: 400})
+	registry.SetOutputLimiter(&OutputLimiter{
+		SpillDir: spillDir,
+		Default:  OutputLimit{MaxLines: 200, MaxBytes: 40 * 1024},
+	})
+
+	result, err := registry.Execute(context.Background(), "exec", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`Full output saved to (\S+) - `).FindStringSubmatch(result)
+	if m == nil {
+		t.Fatalf("no spill file in the truncated result:\n%s", result)
+	}
+	spillFile := m[1]
+
+	// A default 400-line page is well over 40KB
+	seen := 0
+	for offset := 1; offset <= 2000; offset += 400 {
+		page, err := registry.Execute(context.Background(), "read_file", map[string]interface{}{
+			"path":   spillFile,
+			"offset": float64(offset),
+		})
+		if err != nil {
+			t.Fatalf("read_file at offset %d: %v", offset, err)
+		}
+		if strings.Contains(page, "Output truncated") {
+			t.Fatalf("page at offset %d was truncated again", offset)
+		}
+		for i := offset; i < offset+400; i++ {
+			if !strings.Contains(page, fmt.Sprintf("%04d data", i)) {
+				t.Fatalf("page at offset %d is missing line %d", offset, i)
+			}
+			seen++
+		}
+	}
+	if seen != 2000 {
+		t.Errorf("read %d lines back, want 2000", seen)
+	}
+
+	// Other files read with read_file are still limited
+	big := filepath.Join(workspace, "big.txt")
+	registry.Register(&WriteFileTool{Workspace: workspace})
+	if _, err := registry.Execute(context.Background(), "write_file", map[string]interface{}{
+		"path": big, "content": full.String(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	page, err := registry.Execute(context.Background(), "read_file", map[string]interface{}{"path": big})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page, "Output truncated") {
+		t.Error("read_file of a workspace file was not limited")
+	}
+}
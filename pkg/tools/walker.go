@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ErrStopWalk can be returned by a WalkFunc to end the walk early.
+var ErrStopWalk = errors.New("stop walk")
+
+// WalkFunc is called for every file the walker visits. It is called
+// concurrently from several goroutines and must be safe for that.
+type WalkFunc func(path string, info fs.FileInfo) error
+
+// Walker walks a directory tree, skipping paths matched by .gitignore,
+// .ignore and .domiclawignore files, and hands files to a pool of workers.
+type Walker struct {
+	Root    string
+	Workers int  // Concurrent WalkFunc calls (defaults to the number of CPUs)
+	Hidden  bool // Also visit dot-files and dot-directories (.git is always skipped)
+}
+
+// Walk visits every non-ignored file under Root. It stops when ctx is
+// cancelled, when fn returns ErrStopWalk, or on the first other error
+// returned by fn, which Walk then returns.
+func (w *Walker) Walk(ctx context.Context, fn WalkFunc) error {
+	info, err := os.Stat(w.Root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if err := fn(w.Root, info); err != nil && err != ErrStopWalk {
+			return err
+		}
+		return nil
+	}
+
+	workers := w.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type job struct {
+		path string
+		info fs.FileInfo
+	}
+	jobs := make(chan job, workers*4)
+
+	var (
+		errOnce  sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if ctx.Err() != nil {
+					continue // Drain
+				}
+				if err := fn(j.path, j.info); err != nil {
+					if err != ErrStopWalk {
+						errOnce.Do(func() { firstErr = err })
+					}
+					cancel()
+				}
+			}
+		}()
+	}
+
+	rules, prefix := w.baseRules()
+
+	var walkDir func(dir, rel string, rules ignoreRules)
+	walkDir = func(dir, rel string, rules ignoreRules) {
+		rules = rules.with(dir, rel)
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return // Skip unreadable directories
+		}
+
+		for _, entry := range entries {
+			if ctx.Err() != nil {
+				return
+			}
+
+			name := entry.Name()
+			if name == ".git" || (!w.Hidden && strings.HasPrefix(name, ".")) {
+				continue
+			}
+
+			full := filepath.Join(dir, name)
+			entryRel := name
+			if rel != "" {
+				entryRel = rel + "/" + name
+			}
+
+			// Follow symlinks to files, but not to directories (avoids cycles)
+			isDir := entry.IsDir()
+			if entry.Type()&fs.ModeSymlink != 0 {
+				target, err := os.Stat(full)
+				if err != nil || target.IsDir() {
+					continue
+				}
+			}
+
+			if rules.ignored(entryRel, isDir) {
+				continue
+			}
+
+			if isDir {
+				walkDir(full, entryRel, rules)
+				continue
+			}
+
+			info, err := os.Stat(full)
+			if err != nil {
+				continue
+			}
+			select {
+			case jobs <- job{path: full, info: info}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	walkDir(w.Root, prefix, rules)
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	// Cancellation by the caller is an error; stopping via ErrStopWalk is not
+	return parent.Err()
+}
+
+// baseRules returns the ignore rules that apply above Root: the repository's
+// exclude file and the ignore files from the repository root down to Root's
+// parent. Rules match paths relative to the repository root, so prefix is
+// Root's path within the repository ("" if Root is the root, or outside a
+// repository).
+func (w *Walker) baseRules() (ignoreRules, string) {
+	root, err := filepath.Abs(w.Root)
+	if err != nil {
+		return nil, ""
+	}
+	repo := findRepoRoot(root)
+	if repo == "" {
+		return nil, ""
+	}
+	rules := loadIgnoreFile(gitExcludeFile(repo), "")
+	rel, err := filepath.Rel(repo, root)
+	if err != nil || rel == "." {
+		return rules, ""
+	}
+
+	prefix := filepath.ToSlash(rel)
+	dir, base := repo, ""
+	for _, name := range strings.Split(prefix, "/") {
+		rules = rules.with(dir, base)
+		dir = filepath.Join(dir, name)
+		if base == "" {
+			base = name
+		} else {
+			base += "/" + name
+		}
+	}
+	return rules, prefix
+}
+
+// findRepoRoot returns the nearest directory at or above dir that contains
+// .git (a directory, or a file in a worktree), or "" if there is none.
+func findRepoRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// gitExcludeFile returns the path of the repository's info/exclude file. In
+// a worktree, .git is a file pointing at the worktree's git directory, and
+// info/ lives in the main repository's git directory.
+func gitExcludeFile(repo string) string {
+	gitDir := filepath.Join(repo, ".git")
+	if info, err := os.Stat(gitDir); err == nil && !info.IsDir() {
+		data, _ := os.ReadFile(gitDir)
+		gitDir = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir:"))
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(repo, gitDir)
+		}
+		if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+			dir := strings.TrimSpace(string(common))
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(gitDir, dir)
+			}
+			gitDir = dir
+		}
+	}
+	return filepath.Join(gitDir, "info", "exclude")
+}
@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// WebFetchTool retrieves a URL and returns its content, converting HTML
+// pages to markdown.
+type WebFetchTool struct {
+	AllowDomains []string // If set, only these domains (and their subdomains) may be fetched
+	DenyDomains  []string // Never fetched; takes precedence over AllowDomains
+	MaxBytes     int64
+	Timeout      time.Duration
+	MaxRedirects int
+	Client       *http.Client // Optional; defaults to a plain client (tests inject one)
+}
+
+// NewWebFetchTool creates a web fetch tool with default limits.
+func NewWebFetchTool() *WebFetchTool {
+	return &WebFetchTool{
+		MaxBytes:     2 * 1024 * 1024,
+		Timeout:      30 * time.Second,
+		MaxRedirects: 5,
+	}
+}
+
+func (t *WebFetchTool) Name() string { return "web_fetch" }
+
+func (t *WebFetchTool) Description() string {
+	return `Fetch a URL (http or https) and return its content.
+HTML pages are reduced to their main content and converted to markdown; navigation, scripts and styles are removed.
+Plain text, markdown and JSON are returned as-is. Use after web_search to read a result.`
+}
+
+func (t *WebFetchTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "The URL to fetch",
+			},
+			"raw": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Return the HTML source instead of converting it to markdown",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+func (t *WebFetchTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	rawURL, ok := args["url"].(string)
+	if !ok || rawURL == "" {
+		return "", fmt.Errorf("url must be a non-empty string")
+	}
+	raw, _ := args["raw"].(bool)
+
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	if err := t.checkURL(u); err != nil {
+		return "", err
+	}
+
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "DomiClaw/1.0 (web_fetch)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain,text/markdown,application/json;q=0.9,*/*;q=0.5")
+
+	resp, err := t.client().Do(req)
+	if err != nil {
+		var policyErr *fetchPolicyError
+		if errors.As(err, &policyErr) {
+			return "", policyErr
+		}
+		return "", fmt.Errorf("fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("fetch failed: %s - %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.maxBytes()+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	truncated := int64(len(body)) > t.maxBytes()
+	if truncated {
+		body = body[:runeBoundary(string(body), int(t.maxBytes()))]
+	}
+
+	finalURL := resp.Request.URL
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(body)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+
+	var sb strings.Builder
+	switch {
+	case isHTMLType(mediaType) && !raw:
+		doc := parseHTML(toUTF8(body))
+		if title := htmlTitle(doc); title != "" {
+			sb.WriteString("# " + title + "\n\n")
+		}
+		sb.WriteString(fmt.Sprintf("URL: %s\n\n", finalURL))
+		sb.WriteString(htmlToMarkdown(doc, finalURL))
+	case isTextType(mediaType):
+		sb.WriteString(fmt.Sprintf("URL: %s\nContent-Type: %s\n\n", finalURL, mediaType))
+		sb.WriteString(toUTF8(body))
+	default:
+		return fmt.Sprintf("URL: %s\nContent-Type: %s\n\nBinary content (%d bytes) not shown.", finalURL, mediaType, len(body)), nil
+	}
+
+	if truncated {
+		sb.WriteString(fmt.Sprintf("\n\n[Response truncated at %d bytes]", t.maxBytes()))
+	}
+	return sb.String(), nil
+}
+
+// fetchPolicyError reports a URL rejected by the domain or scheme policy.
+type fetchPolicyError struct {
+	msg string
+}
+
+func (e *fetchPolicyError) Error() string { return e.msg }
+
+// checkURL applies the scheme and domain policy to a URL.
+func (t *WebFetchTool) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &fetchPolicyError{fmt.Sprintf("unsupported url scheme %q (only http and https)", u.Scheme)}
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return &fetchPolicyError{"url has no host"}
+	}
+	if matchesDomain(host, t.DenyDomains) {
+		return &fetchPolicyError{fmt.Sprintf("domain %s is denied by policy", host)}
+	}
+	if len(t.AllowDomains) > 0 && !matchesDomain(host, t.AllowDomains) {
+		return &fetchPolicyError{fmt.Sprintf("domain %s is not in the allowed domains", host)}
+	}
+	return nil
+}
+
+// client returns the HTTP client with a redirect policy that applies
+// checkURL and MaxRedirects to every hop.
+func (t *WebFetchTool) client() *http.Client {
+	c := http.Client{}
+	if t.Client != nil {
+		c = *t.Client
+	}
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > t.MaxRedirects {
+			return &fetchPolicyError{fmt.Sprintf("stopped after %d redirects", t.MaxRedirects)}
+		}
+		return t.checkURL(req.URL)
+	}
+	return &c
+}
+
+func (t *WebFetchTool) maxBytes() int64 {
+	if t.MaxBytes > 0 {
+		return t.MaxBytes
+	}
+	return 2 * 1024 * 1024
+}
+
+// matchesDomain reports whether host is one of domains or a subdomain of one.
+func matchesDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(d, "*"), "."))
+		if d != "" && (host == d || strings.HasSuffix(host, "."+d)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHTMLType(mediaType string) bool {
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+func isTextType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-yaml", "application/yaml", "application/toml":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// toUTF8 returns body as a string, decoding it as Latin-1 if it isn't valid UTF-8.
+func toUTF8(body []byte) string {
+	if utf8.Valid(body) {
+		return string(body)
+	}
+	return decodeLatin1(string(body))
+}
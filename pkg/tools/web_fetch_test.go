@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newFetchServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>Test Page</title><script>var x;</script></head>
+<body><nav>Menu</nav><main><h1>Hello</h1><p>Some <b>text</b> with <a href="/other">a link</a>.</p></main></body></html>`)
+	})
+	mux.HandleFunc("/untyped", func(w http.ResponseWriter, r *http.Request) {
+		// No Content-Type: sniffed from the body
+		w.Write([]byte("<!DOCTYPE html><html><body><p>Sniffed</p></body></html>"))
+	})
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok": true}`)
+	})
+	mux.HandleFunc("/notes.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("caf\xe9")) // Latin-1
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG\r\n\x1a\n0123456789"))
+	})
+	mux.HandleFunc("/big.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, strings.Repeat("abcdefghij", 100))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such page", http.StatusNotFound)
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/loop/", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/loop/"), "%d", &n)
+		http.Redirect(w, r, fmt.Sprintf("/loop/%d", n+1), http.StatusFound)
+	})
+	mux.HandleFunc("/away", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://denied.example/", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebFetch(t *testing.T) {
+	srv := newFetchServer(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		raw      bool
+		maxBytes int64
+		want     []string // Substrings of the output
+		notWant  []string
+		wantErr  string
+	}{
+		{
+			name: "html to markdown",
+			path: "/page",
+			want: []string{
+				"# Test Page\n\n",
+				"URL: " + srv.URL + "/page\n\n",
+				"# Hello\n\nSome **text** with [a link](" + srv.URL + "/other).",
+			},
+			notWant: []string{"Menu", "var x", "<p>"},
+		},
+		{
+			name: "raw html",
+			path: "/page",
+			raw:  true,
+			want: []string{"Content-Type: text/html", "<nav>Menu</nav>"},
+		},
+		{
+			name:    "sniffed html",
+			path:    "/untyped",
+			want:    []string{"Sniffed"},
+			notWant: []string{"<p>"},
+		},
+		{
+			name: "json as is",
+			path: "/data.json",
+			want: []string{"Content-Type: application/json\n\n{\"ok\": true}"},
+		},
+		{
+			name: "latin-1 text",
+			path: "/notes.txt",
+			want: []string{"café"},
+		},
+		{
+			name: "binary content",
+			path: "/image.png",
+			want: []string{"Content-Type: image/png", "Binary content (18 bytes) not shown."},
+		},
+		{
+			name:     "size cap",
+			path:     "/big.txt",
+			maxBytes: 100,
+			want:     []string{strings.Repeat("abcdefghij", 10) + "\n\n[Response truncated at 100 bytes]"},
+			notWant:  []string{strings.Repeat("abcdefghij", 11)},
+		},
+		{
+			name: "redirect is followed",
+			path: "/old",
+			want: []string{"URL: " + srv.URL + "/page", "# Hello"},
+		},
+		{
+			name:    "too many redirects",
+			path:    "/loop/0",
+			wantErr: "stopped after 3 redirects",
+		},
+		{
+			name:    "redirect to a denied domain",
+			path:    "/away",
+			wantErr: "domain denied.example is denied by policy",
+		},
+		{
+			name:    "http error",
+			path:    "/missing",
+			wantErr: "404 Not Found - no such page",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := NewWebFetchTool()
+			tool.Client = srv.Client()
+			tool.MaxRedirects = 3
+			tool.DenyDomains = []string{"denied.example"}
+			if tt.maxBytes > 0 {
+				tool.MaxBytes = tt.maxBytes
+			}
+
+			got, err := tool.Execute(context.Background(), map[string]interface{}{
+				"url": srv.URL + tt.path,
+				"raw": tt.raw,
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("output missing %q:\n%s", w, got)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("output contains %q:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWebFetchPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		allow   []string
+		deny    []string
+		wantErr string
+	}{
+		{"scheme", "ftp://example.com/file", nil, nil, `unsupported url scheme "ftp"`},
+		{"no host", "http:///path", nil, nil, "url has no host"},
+		{"denied", "https://ads.example.com/", nil, []string{"example.com"}, "domain ads.example.com is denied by policy"},
+		{"not allowed", "https://other.org/", []string{"example.com"}, nil, "domain other.org is not in the allowed domains"},
+		{"deny beats allow", "https://example.com/", []string{"example.com"}, []string{"*.example.com"}, "is denied by policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := NewWebFetchTool()
+			tool.AllowDomains = tt.allow
+			tool.DenyDomains = tt.deny
+			_, err := tool.Execute(context.Background(), map[string]interface{}{"url": tt.url})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
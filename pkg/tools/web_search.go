@@ -3,37 +3,47 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
-// WebSearchTool searches the web using Brave or Tavily API.
+// SearchResult is a single web search hit.
+type SearchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// SearchBackend queries a web search service.
+type SearchBackend interface {
+	Name() string
+	Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error)
+}
+
+// WebSearchTool searches the web, trying each backend in order until one succeeds.
 type WebSearchTool struct {
-	APIKey     string
-	Provider   string // "brave" or "tavily"
+	Backends   []SearchBackend
 	MaxResults int
-	client     *http.Client
+	CacheTTL   time.Duration // Zero disables caching
+
+	mu    sync.Mutex
+	cache map[string]searchCacheEntry
 }
 
-// NewWebSearchTool creates a new web search tool.
-func NewWebSearchTool(apiKey string, maxResults int) *WebSearchTool {
-	provider := "brave"
-	if strings.HasPrefix(apiKey, "tvly-") {
-		provider = "tavily"
-	}
+// searchCacheEntry is a cached search response.
+type searchCacheEntry struct {
+	output  string
+	expires time.Time
+}
 
+// NewWebSearchTool creates a web search tool over the given backends.
+// The first backend is the primary; the rest are fallbacks.
+func NewWebSearchTool(maxResults int, backends ...SearchBackend) *WebSearchTool {
 	return &WebSearchTool{
-		APIKey:     apiKey,
-		Provider:   provider,
+		Backends:   backends,
 		MaxResults: maxResults,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
 	}
 }
 
@@ -62,148 +72,91 @@ func (t *WebSearchTool) Execute(ctx context.Context, args map[string]interface{}
 		return "", fmt.Errorf("query must be a string")
 	}
 
-	if t.APIKey == "" {
-		return "Web search not configured. Set BRAVE_API_KEY or TAVILY_API_KEY environment variable.", nil
-	}
-
-	switch t.Provider {
-	case "tavily":
-		return t.searchTavily(ctx, query)
-	default:
-		return t.searchBrave(ctx, query)
-	}
-}
-
-// Brave Search API
-func (t *WebSearchTool) searchBrave(ctx context.Context, query string) (string, error) {
-	apiURL := fmt.Sprintf("https://api.search.brave.com/res/v1/web/search?q=%s&count=%d",
-		url.QueryEscape(query), t.MaxResults)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("X-Subscription-Token", t.APIKey)
-
-	resp, err := t.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("search request failed: %w", err)
+	if len(t.Backends) == 0 {
+		return "Web search not configured. Set BRAVE_API_KEY, TAVILY_API_KEY or SEARXNG_URL.", nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("search failed: %s - %s", resp.Status, string(body))
+	key := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if output, ok := t.cached(key); ok {
+		return output, nil
 	}
 
-	var result braveSearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return t.formatBraveResults(result), nil
-}
-
-type braveSearchResponse struct {
-	Web struct {
-		Results []struct {
-			Title       string `json:"title"`
-			URL         string `json:"url"`
-			Description string `json:"description"`
-		} `json:"results"`
-	} `json:"web"`
-}
-
-func (t *WebSearchTool) formatBraveResults(result braveSearchResponse) string {
-	var sb strings.Builder
-	sb.WriteString("Search Results:\n\n")
-
-	for i, r := range result.Web.Results {
-		if i >= t.MaxResults {
-			break
+	var failures []string
+	for _, backend := range t.Backends {
+		results, err := backend.Search(ctx, query, t.MaxResults)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", backend.Name(), err))
+			continue
 		}
-		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, r.Title))
-		sb.WriteString(fmt.Sprintf("   URL: %s\n", r.URL))
-		sb.WriteString(fmt.Sprintf("   %s\n\n", r.Description))
-	}
 
-	if len(result.Web.Results) == 0 {
-		sb.WriteString("No results found.")
+		output := formatSearchResults(results, t.MaxResults)
+		t.store(key, output)
+		if len(failures) > 0 {
+			output = fmt.Sprintf("(Results from %s; %s)\n\n", backend.Name(), strings.Join(failures, "; ")) + output
+		}
+		return output, nil
 	}
 
-	return sb.String()
+	return "", fmt.Errorf("all search backends failed: %s", strings.Join(failures, "; "))
 }
 
-// Tavily Search API
-func (t *WebSearchTool) searchTavily(ctx context.Context, query string) (string, error) {
-	apiURL := "https://api.tavily.com/search"
-
-	reqBody := map[string]interface{}{
-		"api_key":     t.APIKey,
-		"query":       query,
-		"max_results": t.MaxResults,
-	}
-
-	reqData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+// cached returns a live cache entry for key.
+func (t *WebSearchTool) cached(key string) (string, bool) {
+	if t.CacheTTL <= 0 {
+		return "", false
 	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(string(reqData)))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	entry, ok := t.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return "", false
 	}
+	return entry.output, true
+}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := t.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("search request failed: %w", err)
+// store caches output for key, dropping expired entries.
+func (t *WebSearchTool) store(key, output string) {
+	if t.CacheTTL <= 0 {
+		return
 	}
-	defer resp.Body.Close()
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("search failed: %s - %s", resp.Status, string(body))
+	now := time.Now()
+	if t.cache == nil {
+		t.cache = make(map[string]searchCacheEntry)
 	}
-
-	var result tavilySearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+	for k, entry := range t.cache {
+		if now.After(entry.expires) {
+			delete(t.cache, k)
+		}
 	}
-
-	return t.formatTavilyResults(result), nil
-}
-
-type tavilySearchResponse struct {
-	Results []struct {
-		Title   string `json:"title"`
-		URL     string `json:"url"`
-		Content string `json:"content"`
-	} `json:"results"`
+	t.cache[key] = searchCacheEntry{output: output, expires: now.Add(t.CacheTTL)}
 }
 
-func (t *WebSearchTool) formatTavilyResults(result tavilySearchResponse) string {
+func formatSearchResults(results []SearchResult, maxResults int) string {
 	var sb strings.Builder
 	sb.WriteString("Search Results:\n\n")
 
-	for i, r := range result.Results {
-		if i >= t.MaxResults {
+	for i, r := range results {
+		if maxResults > 0 && i >= maxResults {
 			break
 		}
 		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, r.Title))
 		sb.WriteString(fmt.Sprintf("   URL: %s\n", r.URL))
-		// Truncate content if too long
-		content := r.Content
-		if len(content) > 300 {
-			content = content[:297] + "..."
+		// Truncate snippet if too long
+		snippet := strings.Join(strings.Fields(r.Snippet), " ")
+		if len(snippet) > 300 {
+			snippet = snippet[:runeBoundary(snippet, 297)] + "..."
 		}
-		sb.WriteString(fmt.Sprintf("   %s\n\n", content))
+		sb.WriteString(fmt.Sprintf("   %s\n\n", snippet))
 	}
 
-	if len(result.Results) == 0 {
+	if len(results) == 0 {
 		sb.WriteString("No results found.")
 	}
 
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileLock is an advisory, exclusive lock held on a lock file. It
+// serializes writers across processes (flock on Unix, LockFileEx on
+// Windows) and is released automatically if the process dies.
+type FileLock struct {
+	f *os.File
+}
+
+// LockFile blocks until it holds the lock on path, creating the file and
+// its parent directory if needed. Lock a dedicated file rather than the
+// data file itself: an atomic write replaces the data file, which would
+// silently drop a lock held on it.
+func LockFile(path string) (*FileLock, error) {
+	if err := EnsureDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	return &FileLock{f: f}, nil
+}
+
+// TryLockFile is like LockFile but doesn't wait: it returns nil, nil if
+// another process holds the lock.
+func TryLockFile(path string) (*FileLock, error) {
+	if err := EnsureDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	locked, err := tryLockFile(f)
+	if err != nil || !locked {
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		return nil, nil
+	}
+	return &FileLock{f: f}, nil
+}
+
+// Unlock releases the lock.
+func (l *FileLock) Unlock() error {
+	err := unlockFile(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
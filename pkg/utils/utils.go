@@ -50,14 +50,43 @@ func ReadFileString(path string) string {
 	return string(data)
 }
 
-// WriteFileString writes a string to a file.
+// WriteFileString writes a string to a file atomically.
 func WriteFileString(path, content string) error {
+	return WriteFileAtomic(path, []byte(content), 0644)
+}
+
+// WriteFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers never see a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
 	// Ensure parent directory exists
 	dir := filepath.Dir(path)
 	if err := EnsureDir(dir); err != nil {
 		return err
 	}
-	return os.WriteFile(path, []byte(content), 0644)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
 }
 
 // AppendFileString appends a string to a file.